@@ -0,0 +1,331 @@
+package api
+
+import "log"
+
+// FeatureType describes how the values of a feature layer should be interpreted.
+type FeatureType int
+
+const (
+	// FeatureScalar values are quantities in the range [0, Scale).
+	FeatureScalar FeatureType = iota
+	// FeatureCategorical values are IDs or enum values with no numeric relationship.
+	FeatureCategorical
+)
+
+// Feature describes a single feature layer plane. Scale is the number of distinct values
+// (as used by pysc2), or 0 if it depends on the game data (unit types and buffs).
+type Feature struct {
+	Name  string
+	Scale int32
+	Type  FeatureType
+}
+
+// Screen feature layers
+var (
+	ScreenHeightMap          = Feature{"height_map", 256, FeatureScalar}
+	ScreenVisibilityMap      = Feature{"visibility_map", 4, FeatureCategorical} // hidden, fogged, visible, full hidden
+	ScreenCreep              = Feature{"creep", 2, FeatureCategorical}
+	ScreenPower              = Feature{"power", 2, FeatureCategorical}
+	ScreenPlayerID           = Feature{"player_id", 17, FeatureCategorical}
+	ScreenPlayerRelative     = Feature{"player_relative", 5, FeatureCategorical} // values are Alliance
+	ScreenUnitType           = Feature{"unit_type", 0, FeatureCategorical}
+	ScreenSelected           = Feature{"selected", 2, FeatureCategorical}
+	ScreenUnitHitPoints      = Feature{"unit_hit_points", 1600, FeatureScalar}
+	ScreenUnitHitPointsRatio = Feature{"unit_hit_points_ratio", 256, FeatureScalar}
+	ScreenUnitEnergy         = Feature{"unit_energy", 1000, FeatureScalar}
+	ScreenUnitEnergyRatio    = Feature{"unit_energy_ratio", 256, FeatureScalar}
+	ScreenUnitShields        = Feature{"unit_shields", 1000, FeatureScalar}
+	ScreenUnitShieldsRatio   = Feature{"unit_shields_ratio", 256, FeatureScalar}
+	ScreenUnitDensity        = Feature{"unit_density", 16, FeatureScalar}
+	ScreenUnitDensityAa      = Feature{"unit_density_aa", 256, FeatureScalar}
+	ScreenEffects            = Feature{"effects", 16, FeatureCategorical}
+	ScreenHallucinations     = Feature{"hallucinations", 2, FeatureCategorical}
+	ScreenCloaked            = Feature{"cloaked", 2, FeatureCategorical}
+	ScreenBlip               = Feature{"blip", 2, FeatureCategorical}
+	ScreenBuffs              = Feature{"buffs", 0, FeatureCategorical}
+	ScreenBuffDuration       = Feature{"buff_duration", 256, FeatureScalar}
+	ScreenActive             = Feature{"active", 2, FeatureCategorical}
+	ScreenBuildProgress      = Feature{"build_progress", 256, FeatureScalar}
+	ScreenBuildable          = Feature{"buildable", 2, FeatureCategorical}
+	ScreenPathable           = Feature{"pathable", 2, FeatureCategorical}
+	ScreenPlaceholder        = Feature{"placeholder", 2, FeatureCategorical}
+)
+
+// Minimap feature layers
+var (
+	MinimapHeightMap      = Feature{"height_map", 256, FeatureScalar}
+	MinimapVisibilityMap  = Feature{"visibility_map", 4, FeatureCategorical}
+	MinimapCreep          = Feature{"creep", 2, FeatureCategorical}
+	MinimapCamera         = Feature{"camera", 2, FeatureCategorical}
+	MinimapPlayerID       = Feature{"player_id", 17, FeatureCategorical}
+	MinimapPlayerRelative = Feature{"player_relative", 5, FeatureCategorical}
+	MinimapSelected       = Feature{"selected", 2, FeatureCategorical}
+	MinimapUnitType       = Feature{"unit_type", 0, FeatureCategorical}
+	MinimapAlerts         = Feature{"alerts", 2, FeatureCategorical}
+	MinimapBuildable      = Feature{"buildable", 2, FeatureCategorical}
+	MinimapPathable       = Feature{"pathable", 2, FeatureCategorical}
+)
+
+// FeatureLayer is a single feature layer plane along with a description of its values.
+type FeatureLayer struct {
+	Feature
+	Data *ImageData
+}
+
+// IsNil returns true if the layer wasn't included in the observation.
+func (l FeatureLayer) IsNil() bool {
+	return l.Data == nil || l.Data.Size_ == nil
+}
+
+// Width is the horizontal size of the layer.
+func (l FeatureLayer) Width() int32 {
+	return l.Data.GetSize_().GetX()
+}
+
+// Height is the vertical size of the layer.
+func (l FeatureLayer) Height() int32 {
+	return l.Data.GetSize_().GetY()
+}
+
+// Get returns the raw value at (x, y) regardless of the number of bits per pixel.
+// If (x, y) is out of bounds (or the layer is nil) it returns 0.
+func (l FeatureLayer) Get(x, y int32) int32 {
+	if l.IsNil() {
+		return 0
+	}
+	switch l.Data.BitsPerPixel {
+	case 1:
+		if l.Data.Bits().Get(x, y) {
+			return 1
+		}
+		return 0
+	case 8:
+		return int32(l.Data.Bytes().Get(x, y))
+	case 32:
+		return l.Data.Ints().Get(x, y)
+	}
+	log.Panicf("unsupported BitsPerPixel %v", l.Data.BitsPerPixel)
+	return 0
+}
+
+// Scaled returns the value at (x, y) divided by the feature's scale so scalar features are
+// in the range [0, 1). Categorical features (or ones without a scale) are returned unchanged.
+func (l FeatureLayer) Scaled(x, y int32) float32 {
+	v := float32(l.Get(x, y))
+	if l.Type == FeatureScalar && l.Scale > 0 {
+		return v / float32(l.Scale)
+	}
+	return v
+}
+
+// Layers returns all screen feature layers in proto field order.
+func (f *FeatureLayers) Layers() []FeatureLayer {
+	return []FeatureLayer{
+		f.HeightMapLayer(), f.VisibilityMapLayer(), f.CreepLayer(), f.PowerLayer(),
+		f.PlayerIDLayer(), f.UnitTypeLayer(), f.SelectedLayer(), f.UnitHitPointsLayer(),
+		f.UnitEnergyLayer(), f.UnitShieldsLayer(), f.PlayerRelativeLayer(), f.UnitDensityAaLayer(),
+		f.UnitDensityLayer(), f.UnitHitPointsRatioLayer(), f.UnitEnergyRatioLayer(),
+		f.UnitShieldsRatioLayer(), f.EffectsLayer(), f.HallucinationsLayer(), f.CloakedLayer(),
+		f.BlipLayer(), f.BuffsLayer(), f.ActiveLayer(), f.BuffDurationLayer(),
+		f.BuildProgressLayer(), f.BuildableLayer(), f.PathableLayer(), f.PlaceholderLayer(),
+	}
+}
+
+// HeightMapLayer ...
+func (f *FeatureLayers) HeightMapLayer() FeatureLayer {
+	return FeatureLayer{ScreenHeightMap, f.GetHeightMap()}
+}
+
+// VisibilityMapLayer ...
+func (f *FeatureLayers) VisibilityMapLayer() FeatureLayer {
+	return FeatureLayer{ScreenVisibilityMap, f.GetVisibilityMap()}
+}
+
+// CreepLayer ...
+func (f *FeatureLayers) CreepLayer() FeatureLayer {
+	return FeatureLayer{ScreenCreep, f.GetCreep()}
+}
+
+// PowerLayer ...
+func (f *FeatureLayers) PowerLayer() FeatureLayer {
+	return FeatureLayer{ScreenPower, f.GetPower()}
+}
+
+// PlayerIDLayer ...
+func (f *FeatureLayers) PlayerIDLayer() FeatureLayer {
+	return FeatureLayer{ScreenPlayerID, f.GetPlayerId()}
+}
+
+// UnitTypeLayer ...
+func (f *FeatureLayers) UnitTypeLayer() FeatureLayer {
+	return FeatureLayer{ScreenUnitType, f.GetUnitType()}
+}
+
+// SelectedLayer ...
+func (f *FeatureLayers) SelectedLayer() FeatureLayer {
+	return FeatureLayer{ScreenSelected, f.GetSelected()}
+}
+
+// UnitHitPointsLayer ...
+func (f *FeatureLayers) UnitHitPointsLayer() FeatureLayer {
+	return FeatureLayer{ScreenUnitHitPoints, f.GetUnitHitPoints()}
+}
+
+// UnitHitPointsRatioLayer ...
+func (f *FeatureLayers) UnitHitPointsRatioLayer() FeatureLayer {
+	return FeatureLayer{ScreenUnitHitPointsRatio, f.GetUnitHitPointsRatio()}
+}
+
+// UnitEnergyLayer ...
+func (f *FeatureLayers) UnitEnergyLayer() FeatureLayer {
+	return FeatureLayer{ScreenUnitEnergy, f.GetUnitEnergy()}
+}
+
+// UnitEnergyRatioLayer ...
+func (f *FeatureLayers) UnitEnergyRatioLayer() FeatureLayer {
+	return FeatureLayer{ScreenUnitEnergyRatio, f.GetUnitEnergyRatio()}
+}
+
+// UnitShieldsLayer ...
+func (f *FeatureLayers) UnitShieldsLayer() FeatureLayer {
+	return FeatureLayer{ScreenUnitShields, f.GetUnitShields()}
+}
+
+// UnitShieldsRatioLayer ...
+func (f *FeatureLayers) UnitShieldsRatioLayer() FeatureLayer {
+	return FeatureLayer{ScreenUnitShieldsRatio, f.GetUnitShieldsRatio()}
+}
+
+// PlayerRelativeLayer ...
+func (f *FeatureLayers) PlayerRelativeLayer() FeatureLayer {
+	return FeatureLayer{ScreenPlayerRelative, f.GetPlayerRelative()}
+}
+
+// UnitDensityAaLayer ...
+func (f *FeatureLayers) UnitDensityAaLayer() FeatureLayer {
+	return FeatureLayer{ScreenUnitDensityAa, f.GetUnitDensityAa()}
+}
+
+// UnitDensityLayer ...
+func (f *FeatureLayers) UnitDensityLayer() FeatureLayer {
+	return FeatureLayer{ScreenUnitDensity, f.GetUnitDensity()}
+}
+
+// EffectsLayer ...
+func (f *FeatureLayers) EffectsLayer() FeatureLayer {
+	return FeatureLayer{ScreenEffects, f.GetEffects()}
+}
+
+// HallucinationsLayer ...
+func (f *FeatureLayers) HallucinationsLayer() FeatureLayer {
+	return FeatureLayer{ScreenHallucinations, f.GetHallucinations()}
+}
+
+// CloakedLayer ...
+func (f *FeatureLayers) CloakedLayer() FeatureLayer {
+	return FeatureLayer{ScreenCloaked, f.GetCloaked()}
+}
+
+// BlipLayer ...
+func (f *FeatureLayers) BlipLayer() FeatureLayer {
+	return FeatureLayer{ScreenBlip, f.GetBlip()}
+}
+
+// BuffsLayer ...
+func (f *FeatureLayers) BuffsLayer() FeatureLayer {
+	return FeatureLayer{ScreenBuffs, f.GetBuffs()}
+}
+
+// BuffDurationLayer ...
+func (f *FeatureLayers) BuffDurationLayer() FeatureLayer {
+	return FeatureLayer{ScreenBuffDuration, f.GetBuffDuration()}
+}
+
+// ActiveLayer ...
+func (f *FeatureLayers) ActiveLayer() FeatureLayer {
+	return FeatureLayer{ScreenActive, f.GetActive()}
+}
+
+// BuildProgressLayer ...
+func (f *FeatureLayers) BuildProgressLayer() FeatureLayer {
+	return FeatureLayer{ScreenBuildProgress, f.GetBuildProgress()}
+}
+
+// BuildableLayer ...
+func (f *FeatureLayers) BuildableLayer() FeatureLayer {
+	return FeatureLayer{ScreenBuildable, f.GetBuildable()}
+}
+
+// PathableLayer ...
+func (f *FeatureLayers) PathableLayer() FeatureLayer {
+	return FeatureLayer{ScreenPathable, f.GetPathable()}
+}
+
+// PlaceholderLayer ...
+func (f *FeatureLayers) PlaceholderLayer() FeatureLayer {
+	return FeatureLayer{ScreenPlaceholder, f.GetPlaceholder()}
+}
+
+// Layers returns all minimap feature layers in proto field order.
+func (f *FeatureLayersMinimap) Layers() []FeatureLayer {
+	return []FeatureLayer{
+		f.HeightMapLayer(), f.VisibilityMapLayer(), f.CreepLayer(), f.CameraLayer(),
+		f.PlayerIDLayer(), f.PlayerRelativeLayer(), f.SelectedLayer(), f.UnitTypeLayer(),
+		f.AlertsLayer(), f.BuildableLayer(), f.PathableLayer(),
+	}
+}
+
+// HeightMapLayer ...
+func (f *FeatureLayersMinimap) HeightMapLayer() FeatureLayer {
+	return FeatureLayer{MinimapHeightMap, f.GetHeightMap()}
+}
+
+// VisibilityMapLayer ...
+func (f *FeatureLayersMinimap) VisibilityMapLayer() FeatureLayer {
+	return FeatureLayer{MinimapVisibilityMap, f.GetVisibilityMap()}
+}
+
+// CreepLayer ...
+func (f *FeatureLayersMinimap) CreepLayer() FeatureLayer {
+	return FeatureLayer{MinimapCreep, f.GetCreep()}
+}
+
+// CameraLayer ...
+func (f *FeatureLayersMinimap) CameraLayer() FeatureLayer {
+	return FeatureLayer{MinimapCamera, f.GetCamera()}
+}
+
+// PlayerIDLayer ...
+func (f *FeatureLayersMinimap) PlayerIDLayer() FeatureLayer {
+	return FeatureLayer{MinimapPlayerID, f.GetPlayerId()}
+}
+
+// PlayerRelativeLayer ...
+func (f *FeatureLayersMinimap) PlayerRelativeLayer() FeatureLayer {
+	return FeatureLayer{MinimapPlayerRelative, f.GetPlayerRelative()}
+}
+
+// SelectedLayer ...
+func (f *FeatureLayersMinimap) SelectedLayer() FeatureLayer {
+	return FeatureLayer{MinimapSelected, f.GetSelected()}
+}
+
+// UnitTypeLayer ...
+func (f *FeatureLayersMinimap) UnitTypeLayer() FeatureLayer {
+	return FeatureLayer{MinimapUnitType, f.GetUnitType()}
+}
+
+// AlertsLayer ...
+func (f *FeatureLayersMinimap) AlertsLayer() FeatureLayer {
+	return FeatureLayer{MinimapAlerts, f.GetAlerts()}
+}
+
+// BuildableLayer ...
+func (f *FeatureLayersMinimap) BuildableLayer() FeatureLayer {
+	return FeatureLayer{MinimapBuildable, f.GetBuildable()}
+}
+
+// PathableLayer ...
+func (f *FeatureLayersMinimap) PathableLayer() FeatureLayer {
+	return FeatureLayer{MinimapPathable, f.GetPathable()}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"os"
+)
+
+// Image converts the ImageData into an image.Image. 1-bit data becomes black and white,
+// 8-bit data becomes grayscale, 24-bit data (rendered observations) becomes RGB and 32-bit
+// data becomes 16-bit grayscale (clamped to [0, 65535]). It panics for other pixel sizes.
+func (img ImageData) Image() image.Image {
+	w, h := int(img.Size_.X), int(img.Size_.Y)
+	rect := image.Rect(0, 0, w, h)
+
+	switch img.BitsPerPixel {
+	case 1:
+		return img.Bits().ToBytes().Image()
+	case 8:
+		return img.Bytes().Image()
+	case 24:
+		rgba := image.NewRGBA(rect)
+		for i := 0; i < w*h; i++ {
+			copy(rgba.Pix[4*i:4*i+3], img.Data[3*i:3*i+3])
+			rgba.Pix[4*i+3] = 255
+		}
+		return rgba
+	case 32:
+		ints := img.Ints()
+		gray := image.NewGray16(rect)
+		for y := int32(0); y < ints.Height(); y++ {
+			for x := int32(0); x < ints.Width(); x++ {
+				v := ints.Get(x, y)
+				if v < 0 {
+					v = 0
+				} else if v > math.MaxUint16 {
+					v = math.MaxUint16
+				}
+				gray.SetGray16(int(x), int(y), color.Gray16{Y: uint16(v)})
+			}
+		}
+		return gray
+	}
+	log.Panicf("unsupported BitsPerPixel %v", img.BitsPerPixel)
+	return nil
+}
+
+// WritePNG encodes the ImageData as a PNG (see Image for the conversion).
+func (img ImageData) WritePNG(w io.Writer) error {
+	return png.Encode(w, img.Image())
+}
+
+// Image returns a grayscale image that shares pixel data with the ImageDataBytes.
+func (img ImageDataBytes) Image() *image.Gray {
+	return &image.Gray{
+		Pix:    img.data,
+		Stride: int(img.Width()),
+		Rect:   image.Rect(0, 0, int(img.Width()), int(img.Height())),
+	}
+}
+
+// MapImage returns the rendered map as an RGB image, or nil if rendering wasn't enabled.
+func (r *ObservationRender) MapImage() image.Image {
+	if r.GetMap() == nil {
+		return nil
+	}
+	return r.Map.Image()
+}
+
+// MinimapImage returns the rendered minimap as an RGB image, or nil if rendering wasn't enabled.
+func (r *ObservationRender) MinimapImage() image.Image {
+	if r.GetMinimap() == nil {
+		return nil
+	}
+	return r.Minimap.Image()
+}
+
+// Image colorizes the layer for viewing. Scalar features are drawn in grayscale relative to
+// their scale and categorical features use a fixed palette (player relative layers use the
+// same colors as pysc2). It returns nil if the layer wasn't included in the observation.
+func (l FeatureLayer) Image() image.Image {
+	if l.IsNil() {
+		return nil
+	}
+
+	w, h := l.Width(), l.Height()
+	rgba := image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+	for y := int32(0); y < h; y++ {
+		for x := int32(0); x < w; x++ {
+			rgba.SetRGBA(int(x), int(y), l.color(l.Get(x, y)))
+		}
+	}
+	return rgba
+}
+
+// WritePNG encodes the colorized layer as a PNG.
+func (l FeatureLayer) WritePNG(w io.Writer) error {
+	img := l.Image()
+	if img == nil {
+		return fmt.Errorf("feature layer %v not available", l.Name)
+	}
+	return png.Encode(w, img)
+}
+
+var playerRelativePalette = []color.RGBA{
+	{0, 0, 0, 255},       // none
+	{0, 142, 0, 255},     // self
+	{255, 255, 0, 255},   // ally
+	{129, 166, 196, 255}, // neutral
+	{113, 25, 34, 255},   // enemy
+}
+
+// color maps a layer value to a display color.
+func (l FeatureLayer) color(v int32) color.RGBA {
+	if l.Type == FeatureScalar {
+		scale := l.Scale
+		if scale < 2 {
+			scale = 256
+		}
+		c := v * 255 / (scale - 1)
+		if c < 0 {
+			c = 0
+		} else if c > 255 {
+			c = 255
+		}
+		return color.RGBA{uint8(c), uint8(c), uint8(c), 255}
+	}
+
+	if l.Name == ScreenPlayerRelative.Name && v >= 0 && int(v) < len(playerRelativePalette) {
+		return playerRelativePalette[v]
+	}
+	if v == 0 {
+		return color.RGBA{0, 0, 0, 255}
+	}
+
+	// Spread categories around the color wheel using the golden ratio so nearby IDs differ
+	hue := math.Mod(float64(v)*0.618033988749895, 1)
+	return hsvToRGBA(hue, 0.8, 0.95)
+}
+
+func hsvToRGBA(h, s, v float64) color.RGBA {
+	i := math.Floor(h * 6)
+	f := h*6 - i
+	p, q, t := v*(1-s), v*(1-f*s), v*(1-(1-f)*s)
+
+	var r, g, b float64
+	switch int(i) % 6 {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return color.RGBA{uint8(r * 255), uint8(g * 255), uint8(b * 255), 255}
+}
+
+// SavePNG writes img to a PNG file at path.
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
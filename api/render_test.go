@@ -0,0 +1,149 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+)
+
+// Every test image is 3x2 and stored with an upper left origin, so pixel i is at (i%3, i/3).
+func ints(values ...int32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], uint32(v))
+	}
+	return data
+}
+
+func TestImageDataImage(t *testing.T) {
+	rgb := make([]byte, 3*6)
+	for i := range rgb {
+		rgb[i] = byte(10*(i/3) + i%3)
+	}
+
+	tests := []struct {
+		bpp  int32
+		data []byte
+		want func(i int) color.Color
+	}{
+		{1, []byte{0xA8}, func(i int) color.Color {
+			if i%2 == 0 {
+				return color.Gray{255}
+			}
+			return color.Gray{0}
+		}},
+		{8, []byte{0, 10, 20, 30, 40, 50}, func(i int) color.Color {
+			return color.Gray{uint8(10 * i)}
+		}},
+		{24, rgb, func(i int) color.Color {
+			return color.RGBA{uint8(10 * i), uint8(10*i + 1), uint8(10*i + 2), 255}
+		}},
+		{32, ints(0, 1, 300, 70000, -5, 1000), func(i int) color.Color {
+			return color.Gray16{[]uint16{0, 1, 300, 65535, 0, 1000}[i]}
+		}},
+	}
+
+	for _, test := range tests {
+		data := api.ImageData{BitsPerPixel: test.bpp, Size_: &api.Size2DI{X: 3, Y: 2}, Data: test.data}
+		img := data.Image()
+		if b := img.Bounds(); b != image.Rect(0, 0, 3, 2) {
+			t.Errorf("%v bpp: bounds = %v", test.bpp, b)
+			continue
+		}
+		for i := 0; i < 6; i++ {
+			if got, want := img.At(i%3, i/3), test.want(i); got != want {
+				t.Errorf("%v bpp: At(%v, %v) = %v, want %v", test.bpp, i%3, i/3, got, want)
+			}
+		}
+
+		// PNG round trip
+		var buf bytes.Buffer
+		if err := data.WritePNG(&buf); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 6; i++ {
+			r1, g1, b1, a1 := img.At(i%3, i/3).RGBA()
+			r2, g2, b2, a2 := decoded.At(i%3, i/3).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				t.Errorf("%v bpp: decoded pixel %v differs", test.bpp, i)
+			}
+		}
+	}
+}
+
+func TestFeatureLayers(t *testing.T) {
+	height := &api.ImageData{BitsPerPixel: 8, Size_: &api.Size2DI{X: 3, Y: 2}, Data: []byte{0, 64, 128, 192, 255, 1}}
+	relative := &api.ImageData{BitsPerPixel: 8, Size_: &api.Size2DI{X: 3, Y: 2}, Data: []byte{0, 1, 2, 3, 4, 0}}
+	pathable := &api.ImageData{BitsPerPixel: 1, Size_: &api.Size2DI{X: 3, Y: 2}, Data: []byte{0x80}}
+	units := &api.ImageData{BitsPerPixel: 32, Size_: &api.Size2DI{X: 3, Y: 2}, Data: ints(0, 0, 0, 0, 0, 1000)}
+
+	f := &api.FeatureLayers{HeightMap: height, PlayerRelative: relative, Pathable: pathable, UnitType: units}
+	if n := len(f.Layers()); n != 27 {
+		t.Errorf("%v screen layers, want 27", n)
+	}
+	if n := len((&api.FeatureLayersMinimap{}).Layers()); n != 11 {
+		t.Errorf("%v minimap layers, want 11", n)
+	}
+
+	h := f.HeightMapLayer()
+	if h.Name != "height_map" || h.Get(1, 0) != 64 || h.Scaled(2, 0) != 0.5 || h.Get(3, 0) != 0 {
+		t.Errorf("height map = %v, %v, %v", h.Get(1, 0), h.Scaled(2, 0), h.Get(3, 0))
+	}
+	if r := f.PlayerRelativeLayer(); r.Scaled(1, 1) != float32(api.Alliance_Enemy) {
+		t.Errorf("player relative = %v", r.Scaled(1, 1))
+	}
+	if p := f.PathableLayer(); p.Get(0, 0) != 1 || p.Get(1, 0) != 0 {
+		t.Errorf("pathable = %v, %v", p.Get(0, 0), p.Get(1, 0))
+	}
+	if u := f.UnitTypeLayer(); u.Get(2, 1) != 1000 {
+		t.Errorf("unit type = %v", u.Get(2, 1))
+	}
+
+	// Missing layers read as zero and have no image
+	c := f.CreepLayer()
+	if !c.IsNil() || c.Get(0, 0) != 0 || c.Image() != nil {
+		t.Errorf("creep layer should be nil")
+	}
+	if err := c.WritePNG(&bytes.Buffer{}); err == nil {
+		t.Errorf("expected an error writing a nil layer")
+	}
+
+	// Scalar layers are grayscale, player relative uses the pysc2 palette
+	img := h.Image()
+	if got := img.At(2, 0); got != (color.RGBA{128, 128, 128, 255}) {
+		t.Errorf("height color = %v", got)
+	}
+	img = f.PlayerRelativeLayer().Image()
+	if got := img.At(1, 0); got != (color.RGBA{0, 142, 0, 255}) {
+		t.Errorf("self color = %v", got)
+	}
+
+	// SavePNG round trip
+	path := filepath.Join(t.TempDir(), "relative.png")
+	if err := api.SavePNG(path, img); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	decoded, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := decoded.At(1, 1).RGBA(); r>>8 != 113 || g>>8 != 25 || b>>8 != 34 {
+		t.Errorf("decoded enemy color = %v %v %v", r>>8, g>>8, b>>8)
+	}
+}
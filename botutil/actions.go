@@ -2,7 +2,6 @@ package botutil
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/chippydip/go-sc2ai/api"
 	"github.com/chippydip/go-sc2ai/client"
@@ -55,9 +54,9 @@ func (a *Actions) LogActionErrors() {
 			} else {
 				dst = raw.UnitCommand.GetTargetWorldSpacePos().String()
 			}
-			log.Printf("Action %v: %v %v > %v", r, src, abil, dst)
+			a.info.Logger().Warn("Action failed", "result", r, "units", src, "ability", abil, "target", dst)
 		default:
-			log.Printf("Action %v: %v", r, action)
+			a.info.Logger().Warn("Action failed", "result", r, "action", action)
 		}
 	})
 }
@@ -78,7 +77,10 @@ func (a *Actions) Send() {
 		return
 	}
 
-	results := a.info.SendActions(a.actions)
+	results, err := a.info.SendActions(a.actions)
+	if err != nil {
+		a.info.Logger().Error("Failed to send actions", "err", err)
+	}
 	if a.errorHandler != nil {
 		for i, r := range results {
 			if r != api.ActionResult_Success {
@@ -0,0 +1,244 @@
+package botutil
+
+import (
+	"math"
+
+	"github.com/chippydip/go-sc2ai/api"
+)
+
+// SpatialCamera converts between world coordinates and the screen and minimap pixel
+// coordinates of a SpatialCameraSetup (either the feature layer or render interface).
+type SpatialCamera struct {
+	Setup  *api.SpatialCameraSetup
+	Center api.Point2D // world position at the center of the screen
+
+	area  api.RectangleI // world area shown on the minimap
+	scale float32        // world units per minimap pixel
+}
+
+// NewSpatialCamera creates a camera for the given setup centered on the given world point.
+// The map size and playable area come from game info.
+func NewSpatialCamera(setup *api.SpatialCameraSetup, center api.Point2D, gameInfo *api.ResponseGameInfo) SpatialCamera {
+	c := SpatialCamera{Setup: setup, Center: center}
+
+	size := gameInfo.GetStartRaw().GetMapSize()
+	c.area = api.RectangleI{P0: &api.PointI{}, P1: &api.PointI{X: size.GetX(), Y: size.GetY()}}
+	if area := gameInfo.GetStartRaw().GetPlayableArea(); setup.GetCropToPlayableArea() && area != nil {
+		c.area = *area
+	}
+
+	w, h := c.area.P1.X-c.area.P0.X, c.area.P1.Y-c.area.P0.Y
+	if h > w {
+		w = h
+	}
+	if res := setup.GetMinimapResolution().GetX(); res > 0 {
+		c.scale = float32(w) / float32(res)
+	}
+	return c
+}
+
+// screenScale returns the number of world units per screen pixel.
+func (c SpatialCamera) screenScale() float32 {
+	if res := c.Setup.GetResolution().GetX(); res > 0 {
+		return c.Setup.GetWidth() / float32(res)
+	}
+	return 0
+}
+
+// WorldToScreen converts a world position to screen pixel coordinates. The second result is
+// false if the point isn't currently visible on the screen.
+func (c SpatialCamera) WorldToScreen(pt api.Point2D) (api.PointI, bool) {
+	res, scale := c.Setup.GetResolution(), c.screenScale()
+	if scale == 0 {
+		return api.PointI{}, false
+	}
+	p := api.PointI{
+		X: int32(math.Floor(float64((pt.X-c.Center.X)/scale) + float64(res.X)/2)),
+		Y: int32(math.Floor(float64((c.Center.Y-pt.Y)/scale) + float64(res.Y)/2)),
+	}
+	return p, 0 <= p.X && p.X < res.X && 0 <= p.Y && p.Y < res.Y
+}
+
+// ScreenToWorld converts screen pixel coordinates to the world position at the center of
+// that pixel.
+func (c SpatialCamera) ScreenToWorld(p api.PointI) api.Point2D {
+	res, scale := c.Setup.GetResolution(), c.screenScale()
+	return api.Point2D{
+		X: c.Center.X + (float32(p.X)+0.5-float32(res.GetX())/2)*scale,
+		Y: c.Center.Y - (float32(p.Y)+0.5-float32(res.GetY())/2)*scale,
+	}
+}
+
+// WorldToMinimap converts a world position to minimap pixel coordinates. The second result
+// is false if the point is outside the area shown on the minimap.
+func (c SpatialCamera) WorldToMinimap(pt api.Point2D) (api.PointI, bool) {
+	res := c.Setup.GetMinimapResolution()
+	if c.scale == 0 {
+		return api.PointI{}, false
+	}
+	p := api.PointI{
+		X: int32(math.Floor(float64((pt.X - float32(c.area.P0.X)) / c.scale))),
+		Y: int32(math.Floor(float64((float32(c.area.P1.Y) - pt.Y) / c.scale))),
+	}
+	return p, 0 <= p.X && p.X < res.X && 0 <= p.Y && p.Y < res.Y
+}
+
+// MinimapToWorld converts minimap pixel coordinates to the world position at the center of
+// that pixel.
+func (c SpatialCamera) MinimapToWorld(p api.PointI) api.Point2D {
+	return api.Point2D{
+		X: float32(c.area.P0.X) + (float32(p.X)+0.5)*c.scale,
+		Y: float32(c.area.P1.Y) - (float32(p.Y)+0.5)*c.scale,
+	}
+}
+
+// SpatialActions queues ActionSpatial actions for either the feature layer or render
+// interface. All coordinates are in pixels of the chosen interface.
+type SpatialActions struct {
+	a      *Actions
+	render bool
+}
+
+// FeatureLayer returns a builder for feature layer actions.
+func (a *Actions) FeatureLayer() SpatialActions {
+	return SpatialActions{a, false}
+}
+
+// Render returns a builder for rendered interface actions.
+func (a *Actions) Render() SpatialActions {
+	return SpatialActions{a, true}
+}
+
+// Camera returns the coordinate conversion for the current camera position. It requires the
+// raw interface (for the camera position) and the matching interface to be enabled.
+func (s SpatialActions) Camera() SpatialCamera {
+	info := s.a.info
+	setup := info.GameInfo().GetOptions().GetFeatureLayer()
+	if s.render {
+		setup = info.GameInfo().GetOptions().GetRender()
+	}
+
+	var center api.Point2D
+	if cam := info.Observation().GetObservation().GetRawData().GetPlayer().GetCamera(); cam != nil {
+		center = cam.ToPoint2D()
+	}
+	return NewSpatialCamera(setup, center, info.GameInfo())
+}
+
+func (s SpatialActions) add(action *api.ActionSpatial) {
+	if s.render {
+		s.a.actions = append(s.a.actions, &api.Action{ActionRender: action})
+	} else {
+		s.a.actions = append(s.a.actions, &api.Action{ActionFeatureLayer: action})
+	}
+}
+
+// Command uses an ability that doesn't need a target with the currently selected units.
+func (s SpatialActions) Command(ability api.AbilityID, queue bool) {
+	s.add(&api.ActionSpatial{
+		Action: &api.ActionSpatial_UnitCommand{
+			UnitCommand: &api.ActionSpatialUnitCommand{
+				AbilityId:    int32(ability),
+				QueueCommand: queue,
+			},
+		},
+	})
+}
+
+// CommandScreen uses an ability on a screen location with the currently selected units.
+func (s SpatialActions) CommandScreen(ability api.AbilityID, p api.PointI, queue bool) {
+	s.add(&api.ActionSpatial{
+		Action: &api.ActionSpatial_UnitCommand{
+			UnitCommand: &api.ActionSpatialUnitCommand{
+				AbilityId:    int32(ability),
+				Target:       &api.ActionSpatialUnitCommand_TargetScreenCoord{TargetScreenCoord: &p},
+				QueueCommand: queue,
+			},
+		},
+	})
+}
+
+// CommandMinimap uses an ability on a minimap location with the currently selected units.
+func (s SpatialActions) CommandMinimap(ability api.AbilityID, p api.PointI, queue bool) {
+	s.add(&api.ActionSpatial{
+		Action: &api.ActionSpatial_UnitCommand{
+			UnitCommand: &api.ActionSpatialUnitCommand{
+				AbilityId:    int32(ability),
+				Target:       &api.ActionSpatialUnitCommand_TargetMinimapCoord{TargetMinimapCoord: &p},
+				QueueCommand: queue,
+			},
+		},
+	})
+}
+
+// CommandWorld uses an ability on a world location with the currently selected units. The
+// screen is used if the point is visible, otherwise the minimap. It returns false if the
+// point can't be targeted either way.
+func (s SpatialActions) CommandWorld(ability api.AbilityID, pt api.Point2D, queue bool) bool {
+	cam := s.Camera()
+	if p, ok := cam.WorldToScreen(pt); ok {
+		s.CommandScreen(ability, p, queue)
+		return true
+	}
+	if p, ok := cam.WorldToMinimap(pt); ok {
+		s.CommandMinimap(ability, p, queue)
+		return true
+	}
+	return false
+}
+
+// MoveCamera centers the camera on a minimap location.
+func (s SpatialActions) MoveCamera(p api.PointI) {
+	s.add(&api.ActionSpatial{
+		Action: &api.ActionSpatial_CameraMove{
+			CameraMove: &api.ActionSpatialCameraMove{CenterMinimap: &p},
+		},
+	})
+}
+
+// MoveCameraWorld centers the camera as close as possible to a world location using the
+// minimap. It returns false if the point isn't on the minimap.
+func (s SpatialActions) MoveCameraWorld(pt api.Point2D) bool {
+	if p, ok := s.Camera().WorldToMinimap(pt); ok {
+		s.MoveCamera(p)
+		return true
+	}
+	return false
+}
+
+// SelectPoint clicks on a screen location to change the selection.
+func (s SpatialActions) SelectPoint(p api.PointI, selectType api.ActionSpatialUnitSelectionPoint_Type) {
+	s.add(&api.ActionSpatial{
+		Action: &api.ActionSpatial_UnitSelectionPoint{
+			UnitSelectionPoint: &api.ActionSpatialUnitSelectionPoint{
+				SelectionScreenCoord: &p,
+				Type:                 selectType,
+			},
+		},
+	})
+}
+
+// SelectRect drags a selection box between two screen locations, optionally adding to the
+// current selection.
+func (s SpatialActions) SelectRect(p0, p1 api.PointI, add bool) {
+	s.add(&api.ActionSpatial{
+		Action: &api.ActionSpatial_UnitSelectionRect{
+			UnitSelectionRect: &api.ActionSpatialUnitSelectionRect{
+				SelectionScreenCoord: []*api.RectangleI{{P0: &p0, P1: &p1}},
+				SelectionAdd:         add,
+			},
+		},
+	})
+}
+
+// SelectUnit clicks on a unit if it is visible on the screen. It returns false if it isn't.
+func (s SpatialActions) SelectUnit(u Unit, selectType api.ActionSpatialUnitSelectionPoint_Type) bool {
+	if u.IsNil() {
+		return false
+	}
+	if p, ok := s.Camera().WorldToScreen(u.Pos2D()); ok {
+		s.SelectPoint(p, selectType)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package botutil_test
+
+import (
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/botutil"
+	"github.com/chippydip/go-sc2ai/enums/ability"
+)
+
+func TestSpatialCamera(t *testing.T) {
+	setup := &api.SpatialCameraSetup{
+		Resolution:        &api.Size2DI{X: 84, Y: 84},
+		MinimapResolution: &api.Size2DI{X: 64, Y: 64},
+		Width:             24,
+	}
+	gameInfo := &api.ResponseGameInfo{
+		StartRaw: &api.StartRaw{MapSize: &api.Size2DI{X: 128, Y: 96}},
+	}
+	cam := botutil.NewSpatialCamera(setup, api.Point2D{X: 50, Y: 40}, gameInfo)
+
+	if p, ok := cam.WorldToScreen(api.Point2D{X: 50, Y: 40}); !ok || p != (api.PointI{X: 42, Y: 42}) {
+		t.Errorf("WorldToScreen(center) = %v, %v", p, ok)
+	}
+	if _, ok := cam.WorldToScreen(api.Point2D{X: 70, Y: 40}); ok {
+		t.Error("WorldToScreen(off screen) should not be visible")
+	}
+	if p, ok := cam.WorldToMinimap(api.Point2D{X: 1, Y: 95}); !ok || p != (api.PointI{X: 0, Y: 0}) {
+		t.Errorf("WorldToMinimap(top left) = %v, %v", p, ok)
+	}
+
+	for _, pt := range []api.Point2D{{X: 45.1, Y: 38.3}, {X: 55.9, Y: 44.7}} {
+		p, _ := cam.WorldToScreen(pt)
+		if d := cam.ScreenToWorld(p).Distance(pt); d > 24.0/84 {
+			t.Errorf("screen round trip of %v off by %v", pt, d)
+		}
+		p, _ = cam.WorldToMinimap(pt)
+		if d := cam.MinimapToWorld(p).Distance(pt); d > 2 {
+			t.Errorf("minimap round trip of %v off by %v", pt, d)
+		}
+	}
+}
+
+type spatialInfo struct {
+	mockAgentInfo
+	sent []*api.Action
+}
+
+func (i *spatialInfo) GameInfo() *api.ResponseGameInfo {
+	setup := &api.SpatialCameraSetup{
+		Resolution:        &api.Size2DI{X: 84, Y: 84},
+		MinimapResolution: &api.Size2DI{X: 64, Y: 64},
+		Width:             24,
+	}
+	return &api.ResponseGameInfo{
+		StartRaw: &api.StartRaw{MapSize: &api.Size2DI{X: 128, Y: 96}},
+		Options:  &api.InterfaceOptions{FeatureLayer: setup, Render: setup},
+	}
+}
+
+func (i *spatialInfo) Observation() *api.ResponseObservation {
+	return &api.ResponseObservation{Observation: &api.Observation{RawData: &api.ObservationRaw{
+		Player: &api.PlayerRaw{Camera: &api.Point{X: 50, Y: 40}},
+	}}}
+}
+
+func (i *spatialInfo) SendActions(actions []*api.Action) ([]api.ActionResult, error) {
+	i.sent = append(i.sent, actions...)
+	return nil, nil
+}
+
+func TestSpatialActions(t *testing.T) {
+	i := &spatialInfo{}
+	a := botutil.NewActions(i)
+
+	// On screen targets use the screen, anything else the minimap
+	if !a.FeatureLayer().CommandWorld(ability.Move, api.Point2D{X: 50, Y: 40}, false) {
+		t.Error("CommandWorld(on screen) = false")
+	}
+	if !a.Render().CommandWorld(ability.Move, api.Point2D{X: 100, Y: 80}, true) {
+		t.Error("CommandWorld(off screen) = false")
+	}
+	if a.FeatureLayer().MoveCameraWorld(api.Point2D{X: 200, Y: 40}) {
+		t.Error("MoveCameraWorld(off map) = true")
+	}
+	a.FeatureLayer().SelectRect(api.PointI{X: 1, Y: 2}, api.PointI{X: 3, Y: 4}, true)
+	a.Send()
+
+	if len(i.sent) != 3 {
+		t.Fatalf("sent %v actions, want 3", len(i.sent))
+	}
+	if cmd := i.sent[0].GetActionFeatureLayer().GetUnitCommand(); cmd.GetTargetScreenCoord() == nil || cmd.QueueCommand {
+		t.Errorf("screen command = %v", i.sent[0])
+	}
+	if cmd := i.sent[1].GetActionRender().GetUnitCommand(); cmd.GetTargetMinimapCoord() == nil || !cmd.QueueCommand {
+		t.Errorf("minimap command = %v", i.sent[1])
+	}
+	if rect := i.sent[2].GetActionFeatureLayer().GetUnitSelectionRect(); !rect.GetSelectionAdd() || len(rect.GetSelectionScreenCoord()) != 1 {
+		t.Errorf("select rect = %v", i.sent[2])
+	}
+}
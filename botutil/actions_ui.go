@@ -0,0 +1,95 @@
+package botutil
+
+import "github.com/chippydip/go-sc2ai/api"
+
+// addUI wraps an ActionUI and adds it to the action list.
+func (a *Actions) addUI(action *api.ActionUI) {
+	a.actions = append(a.actions, &api.Action{ActionUi: action})
+}
+
+// ControlGroup recalls, sets or appends to a control group (0-9).
+func (a *Actions) ControlGroup(action api.ActionControlGroup_ControlGroupAction, index uint32) {
+	a.addUI(&api.ActionUI{
+		Action: &api.ActionUI_ControlGroup{
+			ControlGroup: &api.ActionControlGroup{
+				Action:            action,
+				ControlGroupIndex: index,
+			},
+		},
+	})
+}
+
+// SelectArmy selects all army units, optionally adding them to the current selection.
+func (a *Actions) SelectArmy(add bool) {
+	a.addUI(&api.ActionUI{
+		Action: &api.ActionUI_SelectArmy{
+			SelectArmy: &api.ActionSelectArmy{SelectionAdd: add},
+		},
+	})
+}
+
+// SelectWarpGates selects all warp gates, optionally adding them to the current selection.
+func (a *Actions) SelectWarpGates(add bool) {
+	a.addUI(&api.ActionUI{
+		Action: &api.ActionUI_SelectWarpGates{
+			SelectWarpGates: &api.ActionSelectWarpGates{SelectionAdd: add},
+		},
+	})
+}
+
+// SelectLarva selects all larva.
+func (a *Actions) SelectLarva() {
+	a.addUI(&api.ActionUI{
+		Action: &api.ActionUI_SelectLarva{
+			SelectLarva: &api.ActionSelectLarva{},
+		},
+	})
+}
+
+// SelectIdleWorker selects (or adds) one or all idle workers.
+func (a *Actions) SelectIdleWorker(selectType api.ActionSelectIdleWorker_Type) {
+	a.addUI(&api.ActionUI{
+		Action: &api.ActionUI_SelectIdleWorker{
+			SelectIdleWorker: &api.ActionSelectIdleWorker{Type: selectType},
+		},
+	})
+}
+
+// MultiPanel clicks on a unit in the multi-unit selection panel.
+func (a *Actions) MultiPanel(panelType api.ActionMultiPanel_Type, unitIndex int32) {
+	a.addUI(&api.ActionUI{
+		Action: &api.ActionUI_MultiPanel{
+			MultiPanel: &api.ActionMultiPanel{
+				Type:      panelType,
+				UnitIndex: unitIndex,
+			},
+		},
+	})
+}
+
+// CargoUnload unloads a unit from the cargo panel of the selected transport.
+func (a *Actions) CargoUnload(unitIndex int32) {
+	a.addUI(&api.ActionUI{
+		Action: &api.ActionUI_CargoPanel{
+			CargoPanel: &api.ActionCargoPanelUnload{UnitIndex: unitIndex},
+		},
+	})
+}
+
+// ProductionCancel removes an item from the production queue of the selected building.
+func (a *Actions) ProductionCancel(unitIndex int32) {
+	a.addUI(&api.ActionUI{
+		Action: &api.ActionUI_ProductionPanel{
+			ProductionPanel: &api.ActionProductionPanelRemoveFromQueue{UnitIndex: unitIndex},
+		},
+	})
+}
+
+// ToggleAutocast toggles autocast of an ability for the selected units.
+func (a *Actions) ToggleAutocast(ability api.AbilityID) {
+	a.addUI(&api.ActionUI{
+		Action: &api.ActionUI_ToggleAutocast{
+			ToggleAutocast: &api.ActionToggleAutocast{AbilityId: ability},
+		},
+	})
+}
@@ -2,7 +2,6 @@ package botutil
 
 import (
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/chippydip/go-sc2ai/client"
@@ -10,6 +9,12 @@ import (
 )
 
 // Bot ...
+//
+// Use Logger() (inherited from client.AgentInfo) rather than the global log package so that
+// output is tagged with the player ID and game loop, and SetLogger to redirect it.
+//
+// Use AutoStep rather than Step so the "!pause" and "!step" chat commands work. When setting a
+// different step policy wrap it with Commands.StepPolicy to keep them working.
 type Bot struct {
 	client.AgentInfo
 	GameLoop uint32
@@ -18,6 +23,9 @@ type Bot struct {
 	*UnitContext
 	*Actions
 	*Builder
+	*Events
+	*UIState
+	*Commands
 }
 
 // NewBot ...
@@ -28,10 +36,13 @@ func NewBot(info client.AgentInfo) *Bot {
 	bot.Actions = NewActions(info)
 	bot.UnitContext = NewUnitContext(info, bot)
 	bot.Builder = NewBuilder(info, bot.Player, bot.UnitContext)
+	bot.Events = NewEvents(info)
+	bot.UIState = NewUIState(info)
+	bot.Commands = NewCommands(info)
+	bot.SetStepPolicy(bot.Commands.StepPolicy(nil))
 
 	update := func() {
 		bot.GameLoop = bot.Observation().GetObservation().GetGameLoop()
-		log.SetPrefix(fmt.Sprintf("[%v] ", bot.GameLoop))
 
 		if bot.GameLoop == 224 {
 			bot.checkVersion()
@@ -45,7 +56,7 @@ func NewBot(info client.AgentInfo) *Bot {
 
 func (bot *Bot) checkVersion() {
 	if c, ok := bot.AgentInfo.(*client.Client); !ok {
-		log.Print("Skipping version check") // Should only happen when AgentInfo is mocked
+		bot.Logger().Info("Skipping version check") // Should only happen when AgentInfo is mocked
 	} else {
 		// Check the game version, this should be less important but still worth reporting
 		cVersion := formatVersion(c.GameVersion, c.BaseBuild)
@@ -1,7 +1,7 @@
 package botutil
 
 import (
-	"log"
+	"fmt"
 
 	"github.com/chippydip/go-sc2ai/api"
 	"github.com/chippydip/go-sc2ai/client"
@@ -146,7 +146,7 @@ func (b *Builder) ProductionCost(producerType api.UnitTypeID, train api.AbilityI
 	// Get the unit that will be built/trained
 	targetType := ability.Produces(train)
 	if targetType == unit.Invalid {
-		log.Panicf("%v does not produce a unit", train)
+		panic(fmt.Sprintf("%v does not produce a unit", train))
 	}
 
 	producer := b.units.data[producerType]
@@ -167,7 +167,7 @@ func (b *Builder) ProductionCost(producerType api.UnitTypeID, train api.AbilityI
 	// Double-check that we have an integer food cost now (do we need to handle anything other than zerglings?)
 	foodMult := food * float32(multiplier)
 	if float32(uint32(foodMult)) != foodMult {
-		log.Panicf("unexpected FoodRequirement: %v -> %v x%v for %v", producer.FoodRequired, target.FoodRequired, multiplier, targetType)
+		panic(fmt.Sprintf("unexpected FoodRequirement: %v -> %v x%v for %v", producer.FoodRequired, target.FoodRequired, multiplier, targetType))
 	}
 
 	// Per-build cost for this unit
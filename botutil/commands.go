@@ -0,0 +1,204 @@
+package botutil
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+// CommandHandler handles a chat command. Args are the whitespace separated words after the
+// command name.
+type CommandHandler func(from api.PlayerID, args []string)
+
+// CommandSource is a set of players that are allowed to issue chat commands.
+type CommandSource int
+
+// Command sources
+const (
+	CommandsFromSelf      CommandSource = 1 << iota // our own player (e.g. a human sharing the game client)
+	CommandsFromObservers                           // observers of the game
+	CommandsFromAnyone                              // anyone, including opponents
+)
+
+// Commands routes chat messages that start with "!" to registered handlers so a running bot
+// can be controlled from the game. The built-in commands are:
+//
+//	!debug [on|off]         toggle DebugEnabled
+//	!draw <layer> [on|off]  toggle DrawEnabled(layer)
+//	!pause                  stop advancing the game (see StepPolicy)
+//	!resume                 continue after !pause
+//	!step [n]               advance n game loops (default 1) while paused
+//	!perf <steps>           set the client perf reporting interval
+//	!surrender              leave the game (or surrender with a debug command in single player games)
+//
+// Only messages from our own player and observers are accepted by default.
+type Commands struct {
+	info     client.AgentInfo
+	handlers map[string]CommandHandler
+	sources  CommandSource
+
+	debug  bool
+	draw   map[string]bool
+	paused bool
+	steps  int
+}
+
+// NewCommands creates a command router that checks for chat messages after every observation.
+func NewCommands(info client.AgentInfo) *Commands {
+	c := &Commands{
+		info:     info,
+		handlers: map[string]CommandHandler{},
+		sources:  CommandsFromSelf | CommandsFromObservers,
+		draw:     map[string]bool{},
+	}
+
+	c.OnCommand("debug", func(from api.PlayerID, args []string) {
+		c.debug = parseToggle(args, 0, !c.debug)
+	})
+	c.OnCommand("draw", func(from api.PlayerID, args []string) {
+		if len(args) > 0 {
+			c.draw[args[0]] = parseToggle(args, 1, !c.draw[args[0]])
+		}
+	})
+	c.OnCommand("pause", func(from api.PlayerID, args []string) {
+		c.paused, c.steps = true, 0
+	})
+	c.OnCommand("resume", func(from api.PlayerID, args []string) {
+		c.paused, c.steps = false, 0
+	})
+	c.OnCommand("step", func(from api.PlayerID, args []string) {
+		c.paused, c.steps = true, parseInt(args, 0, 1)
+	})
+	c.OnCommand("perf", func(from api.PlayerID, args []string) {
+		info.SetPerfInterval(uint32(parseInt(args, 0, 0)))
+	})
+	c.OnCommand("surrender", func(from api.PlayerID, args []string) {
+		// Only multiplayer games can be left, otherwise end the game with a loss
+		err := info.LeaveGame()
+		if err != nil {
+			err = info.SendDebugCommands([]*api.DebugCommand{{
+				Command: &api.DebugCommand_EndGame{
+					EndGame: &api.DebugEndGame{EndResult: api.DebugEndGame_Surrender},
+				},
+			}})
+		}
+		if err != nil {
+			info.Logger().Error("Failed to surrender", "err", err)
+		}
+	})
+
+	info.OnObservation(c.update)
+	return c
+}
+
+// OnCommand registers a handler for "!name ..." chat messages, replacing any existing one
+// (including the built-in commands). Names are case insensitive.
+func (c *Commands) OnCommand(name string, handler CommandHandler) {
+	c.handlers[strings.ToLower(name)] = handler
+}
+
+// AllowCommandsFrom restricts which players may issue commands.
+func (c *Commands) AllowCommandsFrom(sources CommandSource) {
+	c.sources = sources
+}
+
+// DebugEnabled returns true if debugging was turned on with "!debug on".
+func (c *Commands) DebugEnabled() bool {
+	return c.debug
+}
+
+// DrawEnabled returns true if the named layer was turned on with "!draw <layer>".
+func (c *Commands) DrawEnabled(layer string) bool {
+	return c.draw[layer]
+}
+
+// Paused returns true if the game was paused with "!pause" (or "!step").
+func (c *Commands) Paused() bool {
+	return c.paused
+}
+
+// StepPolicy wraps another step policy (nil steps one loop at a time) so that "!pause" and
+// "!step" work with AutoStep. While paused each step only fetches a new observation, so it
+// has no effect in realtime mode. Time spent paused isn't charged by simulated realtime or
+// the step budget.
+func (c *Commands) StepPolicy(inner client.StepPolicy) client.StepPolicy {
+	return client.StepPolicyFunc(func(info client.AgentInfo) int {
+		if !c.paused {
+			if inner == nil {
+				return 1
+			}
+			return inner.StepSize(info)
+		}
+		if c.steps > 0 {
+			n := c.steps
+			c.steps = 0
+			return n
+		}
+		time.Sleep(50 * time.Millisecond) // don't spin while waiting for the next command
+		info.ResetStepTimer()
+		return 0
+	})
+}
+
+func (c *Commands) update() {
+	for _, chat := range c.info.Observation().GetChat() {
+		if !strings.HasPrefix(chat.Message, "!") || !c.allowed(chat.PlayerId) {
+			continue
+		}
+
+		args := strings.Fields(chat.Message[1:])
+		if len(args) == 0 {
+			continue
+		}
+		name := strings.ToLower(args[0])
+		if handler, ok := c.handlers[name]; ok {
+			c.info.Logger().Info("Chat command", "from", chat.PlayerId, "command", name, "args", args[1:])
+			handler(chat.PlayerId, args[1:])
+		}
+	}
+}
+
+// allowed checks whether a player may issue commands.
+func (c *Commands) allowed(player api.PlayerID) bool {
+	if c.sources&CommandsFromAnyone != 0 {
+		return true
+	}
+	if c.sources&CommandsFromSelf != 0 && player == c.info.PlayerID() {
+		return true
+	}
+	if c.sources&CommandsFromObservers != 0 {
+		for _, p := range c.info.GameInfo().GetPlayerInfo() {
+			if p.PlayerId == player && p.Type == api.PlayerType_Observer {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseToggle interprets args[i] as on/off, returning def if it is missing.
+func parseToggle(args []string, i int, def bool) bool {
+	if i >= len(args) {
+		return def
+	}
+	switch strings.ToLower(args[i]) {
+	case "on", "1", "true", "yes":
+		return true
+	case "off", "0", "false", "no":
+		return false
+	}
+	return def
+}
+
+// parseInt interprets args[i] as an integer, returning def if it is missing or invalid.
+func parseInt(args []string, i int, def int) int {
+	if i < len(args) {
+		if n, err := strconv.Atoi(args[i]); err == nil {
+			return n
+		}
+	}
+	return def
+}
@@ -0,0 +1,130 @@
+package botutil_test
+
+import (
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/botutil"
+	"github.com/chippydip/go-sc2ai/sc2test"
+)
+
+type commandsInfo struct {
+	eventsInfo
+}
+
+func (i *commandsInfo) PlayerID() api.PlayerID { return 1 }
+func (i *commandsInfo) GameInfo() *api.ResponseGameInfo {
+	return &api.ResponseGameInfo{PlayerInfo: []*api.PlayerInfo{
+		{PlayerId: 1, Type: api.PlayerType_Participant},
+		{PlayerId: 2, Type: api.PlayerType_Participant},
+		{PlayerId: 3, Type: api.PlayerType_Observer},
+	}}
+}
+
+func (i *commandsInfo) chat(from api.PlayerID, msg string) {
+	i.obs = &api.ResponseObservation{
+		Observation: &api.Observation{},
+		Chat:        []*api.ChatReceived{{PlayerId: from, Message: msg}},
+	}
+	i.update()
+}
+
+func TestCommands(t *testing.T) {
+	i := &commandsInfo{}
+	c := botutil.NewCommands(i)
+
+	var got []string
+	c.OnCommand("Influence", func(from api.PlayerID, args []string) { got = args })
+
+	i.chat(1, "!debug on")
+	i.chat(2, "!draw influence")
+	i.chat(3, "!draw paths")
+	i.chat(1, "!influence a b")
+	if !c.DebugEnabled() || c.DrawEnabled("influence") || !c.DrawEnabled("paths") || len(got) != 2 {
+		t.Errorf("debug = %v, draw = %v %v, args = %v", c.DebugEnabled(),
+			c.DrawEnabled("influence"), c.DrawEnabled("paths"), got)
+	}
+
+	policy := c.StepPolicy(nil)
+	i.chat(1, "!step 10")
+	if n := policy.StepSize(i); n != 10 || !c.Paused() {
+		t.Errorf("StepSize = %v, want 10 (paused = %v)", n, c.Paused())
+	}
+	if n := policy.StepSize(i); n != 0 {
+		t.Errorf("StepSize = %v, want 0", n)
+	}
+	i.chat(3, "!resume")
+	if n := policy.StepSize(i); n != 1 {
+		t.Errorf("StepSize = %v, want 1", n)
+	}
+}
+
+// chatServer is a single player game that sends the queued chat messages in the next
+// observation and rejects leaving the game like the real one does.
+func chatServer() (*sc2test.Server, chan<- string) {
+	chat := make(chan string, 1)
+	s := &sc2test.Server{}
+	s.Handler = func(r *api.Request) *api.Response {
+		switch {
+		case r.GetLeaveGame() != nil:
+			return &api.Response{Error: []string{"leaving is only supported in multiplayer games"}}
+		case r.GetObservation() != nil && s.Status() == api.Status_in_game:
+			select {
+			case msg := <-chat:
+				return &api.Response{Response: &api.Response_Observation{Observation: &api.ResponseObservation{
+					Observation: &api.Observation{GameLoop: s.GameLoop(), RawData: &api.ObservationRaw{Player: &api.PlayerRaw{}}},
+					Chat:        []*api.ChatReceived{{PlayerId: 1, Message: msg}},
+				}}}
+			default:
+			}
+		}
+		return nil // use the default behavior
+	}
+	s.Start()
+	return s, chat
+}
+
+func TestCommandsSurrender(t *testing.T) {
+	s, chat := chatServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	botutil.NewCommands(c)
+
+	chat <- "!surrender"
+	for i := 0; i < 3 && c.IsInGame(); i++ {
+		if err := c.Step(1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.IsInGame() {
+		t.Fatal("still in game after !surrender")
+	}
+	if obs, err := c.GetObservation(); err != nil || len(obs.GetPlayerResult()) != 2 || obs.PlayerResult[0].Result != api.Result_Defeat {
+		t.Errorf("results = %v (%v), want a defeat", obs.GetPlayerResult(), err)
+	}
+}
+
+func TestCommandsPauseSimulatedRealtime(t *testing.T) {
+	s, chat := chatServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	c.SetSimulatedRealtime(true)
+	commands := botutil.NewCommands(c)
+	c.SetStepPolicy(commands.StepPolicy(nil))
+
+	chat <- "!pause"
+	if err := c.AutoStep(); err != nil {
+		t.Fatal(err)
+	}
+	loop := c.Observation().GetObservation().GetGameLoop()
+	for i := 0; i < 3; i++ {
+		if err := c.AutoStep(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.Observation().GetObservation().GetGameLoop(); !commands.Paused() || got != loop {
+		t.Errorf("game loop = %v, want %v while paused", got, loop)
+	}
+}
@@ -0,0 +1,212 @@
+package botutil
+
+import (
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+// Events detects changes between successive observations and reports them to registered
+// handlers. It is updated after every observation (not just every step) so transient events
+// aren't missed in realtime mode.
+//
+// Handlers are called in the following order for each observation:
+//
+//	OnUpgradeComplete
+//	OnUnitDestroyed
+//	OnUnitCreated
+//	OnUnitMorphed
+//	OnBuildingComplete
+//	OnUnitDamaged
+//	OnUnitIdle
+//	OnAlert
+//
+// Units are reported in observation order (dead units in the order the game lists them).
+// Units are compared against their last observed state, so units that leave the observation
+// for a while (e.g. workers inside a refinery or units in a transport) aren't reported as
+// created again when they come back.
+//
+// The observation when Events is created is used as the starting point and doesn't fire any
+// events. If the game loop goes backwards (QuickLoad or RestartGame) the starting point is
+// reset in the same way.
+type Events struct {
+	info client.AgentInfo
+
+	units    map[api.UnitTag]*api.Unit // last observed state of every unit that hasn't died
+	upgrades map[api.UpgradeID]struct{}
+	gameLoop uint32
+
+	upgradeComplete []func(api.UpgradeID)
+	unitDestroyed   []func(*api.Unit)
+	unitCreated     []func(*api.Unit)
+	unitMorphed     []func(*api.Unit, api.UnitTypeID)
+	buildComplete   []func(*api.Unit)
+	unitDamaged     []func(*api.Unit, float32, float32)
+	unitIdle        []func(*api.Unit)
+	alert           []func(api.Alert)
+}
+
+// NewEvents creates a new event tracker that is updated after every observation.
+func NewEvents(info client.AgentInfo) *Events {
+	e := &Events{info: info}
+	e.reset()
+	info.OnObservation(e.update)
+	return e
+}
+
+// OnUpgradeComplete registers a handler for when a research upgrade finishes.
+func (e *Events) OnUpgradeComplete(handler func(upgrade api.UpgradeID)) {
+	e.upgradeComplete = append(e.upgradeComplete, handler)
+}
+
+// OnUnitDestroyed registers a handler for when any unit dies. The unit is the last observed
+// state of it, or only has a Tag if it was never seen.
+func (e *Events) OnUnitDestroyed(handler func(u *api.Unit)) {
+	e.unitDestroyed = append(e.unitDestroyed, handler)
+}
+
+// OnUnitCreated registers a handler for when a new unit of our own appears. Buildings are
+// reported when construction starts.
+func (e *Events) OnUnitCreated(handler func(u *api.Unit)) {
+	e.unitCreated = append(e.unitCreated, handler)
+}
+
+// OnUnitMorphed registers a handler for when one of our units changes type without changing
+// its tag (e.g. sieging tanks, morphing hatcheries or burrowing).
+func (e *Events) OnUnitMorphed(handler func(u *api.Unit, from api.UnitTypeID)) {
+	e.unitMorphed = append(e.unitMorphed, handler)
+}
+
+// OnBuildingComplete registers a handler for when one of our units finishes construction.
+func (e *Events) OnBuildingComplete(handler func(u *api.Unit)) {
+	e.buildComplete = append(e.buildComplete, handler)
+}
+
+// OnUnitDamaged registers a handler for when any visible unit loses health or shields. The
+// amounts lost since the previous observation are passed to the handler.
+func (e *Events) OnUnitDamaged(handler func(u *api.Unit, health, shield float32)) {
+	e.unitDamaged = append(e.unitDamaged, handler)
+}
+
+// OnUnitIdle registers a handler for when one of our units has no orders after previously
+// having some, or when it is created or completed without any.
+func (e *Events) OnUnitIdle(handler func(u *api.Unit)) {
+	e.unitIdle = append(e.unitIdle, handler)
+}
+
+// OnAlert registers a handler for game alerts (e.g. NuclearLaunchDetected).
+func (e *Events) OnAlert(handler func(alert api.Alert)) {
+	e.alert = append(e.alert, handler)
+}
+
+// reset records the current observation as the starting point.
+func (e *Events) reset() {
+	obs := e.info.Observation().GetObservation()
+
+	e.units = map[api.UnitTag]*api.Unit{}
+	for _, u := range obs.GetRawData().GetUnits() {
+		e.units[u.Tag] = u
+	}
+
+	e.upgrades = map[api.UpgradeID]struct{}{}
+	for _, upgrade := range obs.GetRawData().GetPlayer().GetUpgradeIds() {
+		e.upgrades[upgrade] = struct{}{}
+	}
+
+	e.gameLoop = obs.GetGameLoop()
+}
+
+func (e *Events) update() {
+	obs := e.info.Observation().GetObservation()
+	if obs.GetGameLoop() < e.gameLoop {
+		e.reset()
+		return
+	}
+	e.gameLoop = obs.GetGameLoop()
+
+	for _, upgrade := range obs.GetRawData().GetPlayer().GetUpgradeIds() {
+		if _, ok := e.upgrades[upgrade]; !ok {
+			e.upgrades[upgrade] = struct{}{}
+			for _, handler := range e.upgradeComplete {
+				handler(upgrade)
+			}
+		}
+	}
+
+	for _, tag := range obs.GetRawData().GetEvent().GetDeadUnits() {
+		u, ok := e.units[tag]
+		if !ok {
+			u = &api.Unit{Tag: tag}
+		}
+		delete(e.units, tag)
+		for _, handler := range e.unitDestroyed {
+			handler(u)
+		}
+	}
+
+	units := obs.GetRawData().GetUnits()
+	prev := make(map[api.UnitTag]*api.Unit, len(units))
+	for _, u := range units {
+		if p, ok := e.units[u.Tag]; ok {
+			prev[u.Tag] = p
+		}
+		e.units[u.Tag] = u
+	}
+
+	for _, u := range units {
+		if _, ok := prev[u.Tag]; !ok && u.Alliance == api.Alliance_Self {
+			for _, handler := range e.unitCreated {
+				handler(u)
+			}
+		}
+	}
+
+	for _, u := range units {
+		if p, ok := prev[u.Tag]; ok && u.Alliance == api.Alliance_Self && u.UnitType != p.UnitType {
+			for _, handler := range e.unitMorphed {
+				handler(u, p.UnitType)
+			}
+		}
+	}
+
+	for _, u := range units {
+		if p, ok := prev[u.Tag]; ok && u.Alliance == api.Alliance_Self && p.BuildProgress < 1 && u.BuildProgress == 1 {
+			for _, handler := range e.buildComplete {
+				handler(u)
+			}
+		}
+	}
+
+	for _, u := range units {
+		if p, ok := prev[u.Tag]; ok {
+			health, shield := p.Health-u.Health, p.Shield-u.Shield
+			if health > 0 || shield > 0 {
+				if health < 0 {
+					health = 0
+				}
+				if shield < 0 {
+					shield = 0
+				}
+				for _, handler := range e.unitDamaged {
+					handler(u, health, shield)
+				}
+			}
+		}
+	}
+
+	for _, u := range units {
+		if u.Alliance != api.Alliance_Self || len(u.Orders) > 0 || u.BuildProgress < 1 {
+			continue
+		}
+		if p, ok := prev[u.Tag]; !ok || len(p.Orders) > 0 || p.BuildProgress < 1 {
+			for _, handler := range e.unitIdle {
+				handler(u)
+			}
+		}
+	}
+
+	for _, alert := range obs.GetAlerts() {
+		for _, handler := range e.alert {
+			handler(alert)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package botutil_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/botutil"
+	"github.com/chippydip/go-sc2ai/enums/terran"
+)
+
+type eventsInfo struct {
+	mockAgentInfo
+	obs    *api.ResponseObservation
+	update func()
+}
+
+func (i *eventsInfo) Observation() *api.ResponseObservation { return i.obs }
+func (i *eventsInfo) OnObservation(f func())                { i.update = f }
+
+func (i *eventsInfo) observe(loop uint32, units []*api.Unit, dead ...api.UnitTag) {
+	i.obs = &api.ResponseObservation{
+		Observation: &api.Observation{
+			GameLoop: loop,
+			RawData: &api.ObservationRaw{
+				Units: units,
+				Event: &api.Event{DeadUnits: dead},
+			},
+		},
+	}
+	if i.update != nil {
+		i.update()
+	}
+}
+
+func TestEvents(t *testing.T) {
+	i := &eventsInfo{}
+	scv := &api.Unit{Tag: 1, UnitType: terran.SCV, Alliance: api.Alliance_Self, BuildProgress: 1, Health: 45,
+		Orders: []*api.UnitOrder{{}}}
+	marine := &api.Unit{Tag: 2, UnitType: terran.Marine, Alliance: api.Alliance_Enemy, BuildProgress: 1, Health: 45}
+	i.observe(0, []*api.Unit{scv, marine})
+
+	var got []string
+	e := botutil.NewEvents(i)
+	e.OnUnitDestroyed(func(u *api.Unit) { got = append(got, fmt.Sprint("destroyed ", u.Tag)) })
+	e.OnUnitCreated(func(u *api.Unit) { got = append(got, fmt.Sprint("created ", u.Tag)) })
+	e.OnBuildingComplete(func(u *api.Unit) { got = append(got, fmt.Sprint("complete ", u.Tag)) })
+	e.OnUnitDamaged(func(u *api.Unit, health, shield float32) {
+		got = append(got, fmt.Sprint("damaged ", u.Tag, " ", health))
+	})
+	e.OnUnitIdle(func(u *api.Unit) { got = append(got, fmt.Sprint("idle ", u.Tag)) })
+
+	depot := &api.Unit{Tag: 3, UnitType: terran.SupplyDepot, Alliance: api.Alliance_Self, BuildProgress: 0.5}
+	hurt := *marine
+	hurt.Health = 39
+	i.observe(10, []*api.Unit{scv, &hurt, depot})
+
+	done := *depot
+	done.BuildProgress = 1
+	idle := *scv
+	idle.Orders = nil
+	i.observe(20, []*api.Unit{&idle, &done}, marine.Tag)
+
+	want := []string{
+		"created 3",
+		"damaged 2 6",
+		"destroyed 2",
+		"complete 3",
+		"idle 1",
+		"idle 3",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEventsUnitReappears(t *testing.T) {
+	i := &eventsInfo{}
+	scv := &api.Unit{Tag: 1, UnitType: terran.SCV, Alliance: api.Alliance_Self, BuildProgress: 1, Health: 45,
+		Orders: []*api.UnitOrder{{}}}
+	marine := &api.Unit{Tag: 2, UnitType: terran.Marine, Alliance: api.Alliance_Self, BuildProgress: 1, Health: 45,
+		Orders: []*api.UnitOrder{{}}}
+	i.observe(0, []*api.Unit{scv, marine})
+
+	var got []string
+	e := botutil.NewEvents(i)
+	e.OnUnitDestroyed(func(u *api.Unit) { got = append(got, fmt.Sprint("destroyed ", u.Tag, " ", u.UnitType)) })
+	e.OnUnitCreated(func(u *api.Unit) { got = append(got, fmt.Sprint("created ", u.Tag)) })
+	e.OnUnitIdle(func(u *api.Unit) { got = append(got, fmt.Sprint("idle ", u.Tag)) })
+
+	// The SCV goes into a refinery and the marine into a bunker
+	i.observe(10, nil)
+	i.observe(20, []*api.Unit{scv})
+
+	// The marine dies inside the bunker
+	i.observe(30, []*api.Unit{scv}, marine.Tag)
+
+	want := []string{
+		fmt.Sprint("destroyed 2 ", terran.Marine),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,12 @@
 package botutil_test
 
-import "github.com/chippydip/go-sc2ai/api"
+import (
+	"context"
+	"log/slog"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
 
 type mockAgentInfo struct{}
 
@@ -36,20 +42,59 @@ func (a *mockAgentInfo) IsInGame() bool {
 func (a *mockAgentInfo) Step(stepSize int) error {
 	return nil
 }
+func (a *mockAgentInfo) StepContext(ctx context.Context, stepSize int) error {
+	return nil
+}
+func (a *mockAgentInfo) StepAsync(stepSize int) *client.StepFuture {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) StepAsyncContext(ctx context.Context, stepSize int) *client.StepFuture {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) AutoStep() error {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) SetStepPolicy(policy client.StepPolicy) {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) WakeAt(gameLoop uint32) {
+	panic("Not Implemented")
+}
 
-func (a *mockAgentInfo) Query(query api.RequestQuery) *api.ResponseQuery {
+func (a *mockAgentInfo) Query(query api.RequestQuery) (*api.ResponseQuery, error) {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) QueryContext(ctx context.Context, query api.RequestQuery) (*api.ResponseQuery, error) {
 	panic("Not Implemented")
 }
-func (a *mockAgentInfo) SendActions(actions []*api.Action) []api.ActionResult {
+func (a *mockAgentInfo) QueryAsync(query api.RequestQuery) *client.QueryFuture {
 	panic("Not Implemented")
 }
-func (a *mockAgentInfo) SendObserverActions(obsActions []*api.ObserverAction) {
+func (a *mockAgentInfo) QueryAsyncContext(ctx context.Context, query api.RequestQuery) *client.QueryFuture {
+	panic("Not Implemented")
 }
-func (a *mockAgentInfo) SendDebugCommands(commands []*api.DebugCommand) {
+func (a *mockAgentInfo) SendActions(actions []*api.Action) ([]api.ActionResult, error) {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) SendActionsContext(ctx context.Context, actions []*api.Action) ([]api.ActionResult, error) {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) SendObserverActions(obsActions []*api.ObserverAction) error {
+	return nil
+}
+func (a *mockAgentInfo) SendDebugCommands(commands []*api.DebugCommand) error {
+	return nil
+}
+func (a *mockAgentInfo) SendDebugCommandsContext(ctx context.Context, commands []*api.DebugCommand) error {
+	return nil
 }
 func (a *mockAgentInfo) ClearDebugDraw() {
 }
-func (a *mockAgentInfo) LeaveGame() {
+func (a *mockAgentInfo) LeaveGame() error {
+	return nil
+}
+func (a *mockAgentInfo) SaveReplay(path string) error {
+	return nil
 }
 
 func (a *mockAgentInfo) OnBeforeStep(func()) {
@@ -58,6 +103,31 @@ func (a *mockAgentInfo) OnObservation(func()) {
 }
 func (a *mockAgentInfo) OnAfterStep(func()) {
 }
+func (a *mockAgentInfo) OnGameEnd(func()) {
+}
 
 func (a *mockAgentInfo) SetPerfInterval(steps uint32) {
 }
+func (a *mockAgentInfo) SetStepBudget(budget client.StepBudget) {
+}
+func (a *mockAgentInfo) ResetStepTimer() {
+}
+
+func (a *mockAgentInfo) QuickSave() error {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) QuickLoad() error {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) RestartGame() error {
+	panic("Not Implemented")
+}
+func (a *mockAgentInfo) MapCommand(triggerCmd string) error {
+	panic("Not Implemented")
+}
+
+func (a *mockAgentInfo) Logger() *slog.Logger {
+	return slog.Default()
+}
+func (a *mockAgentInfo) SetLogger(logger *slog.Logger) {
+}
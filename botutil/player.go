@@ -1,8 +1,6 @@
 package botutil
 
 import (
-	"log"
-
 	"github.com/chippydip/go-sc2ai/api"
 	"github.com/chippydip/go-sc2ai/client"
 )
@@ -37,7 +35,7 @@ func NewPlayer(info client.AgentInfo) *Player {
 				if u.GetOwner() == p.OpponentID {
 					data := info.Data().GetUnits()[u.GetUnitType()]
 					p.OpponentRace = data.GetRace()
-					log.Printf("Detected OpponentRace: %v", p.OpponentRace)
+					info.Logger().Info("Detected OpponentRace", "race", p.OpponentRace)
 					break
 				}
 			}
@@ -71,11 +71,12 @@ func (q *Query) PlacementWithUnit(tag api.UnitTag, ability api.AbilityID, pos ap
 }
 
 // Execute runs the query and returns the result
-func (q *Query) Execute() QueryResult {
+func (q *Query) Execute() (QueryResult, error) {
+	resp, err := q.info.Query(q.request)
 	return QueryResult{
 		request:  q.request,
-		response: q.info.Query(q.request),
-	}
+		response: resp,
+	}, err
 }
 
 // QueryResult ...
@@ -0,0 +1,198 @@
+package botutil
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+// ScoreSample is the score at a single point in the game.
+type ScoreSample struct {
+	GameLoop uint32           `json:"game_loop"`
+	Score    int32            `json:"score"`
+	Details  api.ScoreDetails `json:"details"`
+}
+
+// ScoreRecorder samples the score details (collection rates, idle time, value killed and lost
+// per category, resources used per category, etc) every few game loops so they can be saved
+// as a time series. The score interface option must be enabled (which it is by default).
+type ScoreRecorder struct {
+	info     client.AgentInfo
+	interval uint32
+	next     uint32
+	samples  []ScoreSample
+	path     string
+}
+
+// NewScoreRecorder creates a recorder that samples the score every interval game loops as
+// well as at the end of the game.
+func NewScoreRecorder(info client.AgentInfo, interval uint32) *ScoreRecorder {
+	if interval == 0 {
+		interval = 224
+	}
+	r := &ScoreRecorder{info: info, interval: interval}
+	r.update()
+	info.OnObservation(r.update)
+	info.OnGameEnd(r.end)
+	return r
+}
+
+// SaveOnGameEnd writes the samples to path when the game ends. The format is CSV if the
+// file extension is .csv and JSON otherwise.
+func (r *ScoreRecorder) SaveOnGameEnd(path string) {
+	r.path = path
+}
+
+// Samples returns the recorded samples in game loop order.
+func (r *ScoreRecorder) Samples() []ScoreSample {
+	return r.samples
+}
+
+func (r *ScoreRecorder) update() {
+	r.record(false)
+}
+
+func (r *ScoreRecorder) end() {
+	r.record(true)
+}
+
+func (r *ScoreRecorder) record(ended bool) {
+	obs := r.info.Observation()
+	loop := obs.GetObservation().GetGameLoop()
+	ended = ended || len(obs.GetPlayerResult()) > 0
+
+	// Start over if the game was restarted or reloaded
+	for len(r.samples) > 0 && r.samples[len(r.samples)-1].GameLoop > loop {
+		r.samples = r.samples[:len(r.samples)-1]
+		r.next = loop
+	}
+
+	if loop >= r.next || ended {
+		score := obs.GetObservation().GetScore()
+		sample := ScoreSample{GameLoop: loop, Score: score.GetScore()}
+		if details := score.GetScoreDetails(); details != nil {
+			sample.Details = *details
+		}
+		if n := len(r.samples); n > 0 && r.samples[n-1].GameLoop == loop {
+			r.samples[n-1] = sample
+		} else {
+			r.samples = append(r.samples, sample)
+		}
+		r.next = loop - loop%r.interval + r.interval
+	}
+
+	if ended && r.path != "" {
+		if err := r.Save(r.path); err != nil {
+			r.info.Logger().Error("Failed to save score", "path", r.path, "err", err)
+		}
+		r.path = ""
+	}
+}
+
+// Save writes the samples to a file. The format is CSV if the file extension is .csv and
+// JSON otherwise.
+func (r *ScoreRecorder) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		err = r.WriteCSV(f)
+	} else {
+		err = r.WriteJSON(f)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteJSON writes the samples as a JSON array.
+func (r *ScoreRecorder) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.samples)
+}
+
+// WriteCSV writes the samples as CSV with one row per sample. Nested score categories are
+// flattened into columns such as "killed_minerals.army".
+func (r *ScoreRecorder) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+
+	header := []string{"game_loop", "score"}
+	header = scoreColumns(header, reflect.TypeOf(api.ScoreDetails{}), "")
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, s := range r.samples {
+		row := []string{strconv.FormatUint(uint64(s.GameLoop), 10), strconv.FormatInt(int64(s.Score), 10)}
+		row = scoreValues(row, reflect.ValueOf(s.Details))
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// scoreName returns the column name of a score field from its json tag.
+func scoreName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return ""
+	}
+	return name
+}
+
+// scoreColumns appends the names of all float fields in t (recursing into nested details).
+func scoreColumns(columns []string, t reflect.Type, prefix string) []string {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := scoreName(f)
+		if name == "" {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Float32:
+			columns = append(columns, prefix+name)
+		case reflect.Ptr:
+			columns = scoreColumns(columns, f.Type.Elem(), prefix+name+".")
+		}
+	}
+	return columns
+}
+
+// scoreValues appends the values of all float fields in v in the same order as scoreColumns.
+// Missing nested details are written as zeros.
+func scoreValues(row []string, v reflect.Value) []string {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if scoreName(f) == "" {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Float32:
+			row = append(row, strconv.FormatFloat(v.Field(i).Float(), 'g', -1, 32))
+		case reflect.Ptr:
+			elem := v.Field(i)
+			if elem.IsNil() {
+				elem = reflect.New(f.Type.Elem())
+			}
+			row = scoreValues(row, elem.Elem())
+		}
+	}
+	return row
+}
@@ -0,0 +1,92 @@
+package botutil_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/botutil"
+	"github.com/chippydip/go-sc2ai/sc2test"
+)
+
+func (i *eventsInfo) observeScore(loop uint32, minerals float32, ended bool) {
+	i.obs = &api.ResponseObservation{
+		Observation: &api.Observation{
+			GameLoop: loop,
+			Score: &api.Score{Score: int32(loop), ScoreDetails: &api.ScoreDetails{
+				CollectedMinerals: minerals,
+				KilledMinerals:    &api.CategoryScoreDetails{Army: minerals / 2},
+			}},
+		},
+	}
+	if ended {
+		i.obs.PlayerResult = []*api.PlayerResult{{PlayerId: 1, Result: api.Result_Victory}}
+	}
+	if i.update != nil {
+		i.update()
+	}
+}
+
+func TestScoreRecorder(t *testing.T) {
+	i := &eventsInfo{}
+	i.observeScore(0, 0, false)
+
+	r := botutil.NewScoreRecorder(i, 100)
+	for loop := uint32(10); loop < 250; loop += 10 {
+		i.observeScore(loop, float32(loop), false)
+	}
+	i.observeScore(250, 250, true)
+
+	var loops []uint32
+	for _, s := range r.Samples() {
+		loops = append(loops, s.GameLoop)
+	}
+	if len(loops) != 4 || loops[0] != 0 || loops[1] != 100 || loops[2] != 200 || loops[3] != 250 {
+		t.Errorf("sampled loops = %v, want [0 100 200 250]", loops)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 || !strings.Contains(lines[0], "killed_minerals.army") {
+		t.Fatalf("unexpected CSV:\n%v", buf.String())
+	}
+	if !strings.HasPrefix(lines[2], "100,100,") {
+		t.Errorf("row = %v", lines[2])
+	}
+}
+
+func TestScoreRecorderSaveOnGameEnd(t *testing.T) {
+	s := &sc2test.Server{EndLoop: 250}
+	s.Start()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	r := botutil.NewScoreRecorder(c, 100)
+	path := filepath.Join(t.TempDir(), "score.json")
+	r.SaveOnGameEnd(path)
+
+	for c.IsInGame() {
+		if err := c.Step(16); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var samples []botutil.ScoreSample
+	if err := json.Unmarshal(data, &samples); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(samples); n == 0 || samples[n-1].GameLoop != 250 {
+		t.Errorf("saved samples = %+v, want the last at loop 250", samples)
+	}
+}
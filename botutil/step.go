@@ -0,0 +1,42 @@
+package botutil
+
+import "github.com/chippydip/go-sc2ai/client"
+
+// AdaptiveStep is a client.StepPolicy that takes small steps while any enemy unit is close to
+// being in weapons range of one of our units (or vice versa) and large steps otherwise. Use it
+// with SetStepPolicy and AutoStep, and WakeAt for anything else that needs a timely response.
+type AdaptiveStep struct {
+	ctx *UnitContext
+
+	// Combat is the step size while units are in range, the default is 1.
+	Combat int
+	// Idle is the step size when nothing is happening, the default is 16.
+	Idle int
+	// Margin is how far outside of weapons range units are still considered in range, the
+	// default is 4 which is about how far most units move during a large step.
+	Margin float32
+}
+
+// NewAdaptiveStep creates a new step policy that checks the units in ctx.
+func NewAdaptiveStep(ctx *UnitContext) *AdaptiveStep {
+	return &AdaptiveStep{ctx: ctx, Combat: 1, Idle: 16, Margin: 4}
+}
+
+// StepSize implements client.StepPolicy.
+func (s *AdaptiveStep) StepSize(info client.AgentInfo) int {
+	if s.InCombat() {
+		return s.Combat
+	}
+	return s.Idle
+}
+
+// InCombat returns true if any enemy unit is within Margin of weapons range of our units or
+// any of our units is within Margin of weapons range of an enemy.
+func (s *AdaptiveStep) InCombat() bool {
+	self := s.ctx.Self.All()
+	return s.ctx.Enemy.All().EachUntil(func(enemy Unit) bool {
+		return self.EachUntil(func(u Unit) bool {
+			return enemy.IsInWeaponsRange(u, s.Margin) || u.IsInWeaponsRange(enemy, s.Margin)
+		})
+	})
+}
@@ -0,0 +1,229 @@
+package botutil
+
+import (
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+// UIPanel is the kind of selection panel currently shown in the UI.
+type UIPanel int
+
+// Selection panel types
+const (
+	PanelNone UIPanel = iota
+	PanelSingle
+	PanelMulti
+	PanelCargo
+	PanelProduction
+)
+
+// NumControlGroups is the number of control groups available in the UI.
+const NumControlGroups = 10
+
+// UIState tracks the UI part of the observation (selection, control groups and production
+// queues) for bots that use the feature layer or render interfaces. It is updated after every
+// observation and notifies registered handlers of changes. Units are only described by
+// api.UnitInfo since the UI doesn't expose unit tags.
+type UIState struct {
+	info client.AgentInfo
+
+	ui        *api.ObservationUI
+	panel     UIPanel
+	selection []*api.UnitInfo
+
+	groups        [NumControlGroups]api.ControlGroup
+	groupsChanged [NumControlGroups]uint32
+
+	selectionChanged  []func()
+	groupChanged      []func(group api.ControlGroup)
+	productionChanged []func(queue []*api.BuildItem)
+}
+
+// NewUIState creates a new UI tracker that is updated after every observation.
+func NewUIState(info client.AgentInfo) *UIState {
+	ui := &UIState{info: info}
+	ui.update()
+	info.OnObservation(ui.update)
+	return ui
+}
+
+// OnSelectionChanged registers a handler for when the number or types of selected units change.
+func (ui *UIState) OnSelectionChanged(handler func()) {
+	ui.selectionChanged = append(ui.selectionChanged, handler)
+}
+
+// OnControlGroupChanged registers a handler for when the size or leader of a control group
+// changes. An empty group has a Count of zero.
+func (ui *UIState) OnControlGroupChanged(handler func(group api.ControlGroup)) {
+	ui.groupChanged = append(ui.groupChanged, handler)
+}
+
+// OnProductionChanged registers a handler for when items are added to, removed from or finish
+// in the production queue of the selected building (or a different building is selected).
+// The UI doesn't include unit tags, so switching between two buildings of the same type with
+// identical queues and progress can't be detected.
+func (ui *UIState) OnProductionChanged(handler func(queue []*api.BuildItem)) {
+	ui.productionChanged = append(ui.productionChanged, handler)
+}
+
+// UIData returns the raw UI observation.
+func (ui *UIState) UIData() *api.ObservationUI {
+	return ui.ui
+}
+
+// Panel returns the kind of selection panel currently shown.
+func (ui *UIState) Panel() UIPanel {
+	return ui.panel
+}
+
+// Selection returns every selected unit. For cargo and production panels this is the
+// transport or building itself.
+func (ui *UIState) Selection() []*api.UnitInfo {
+	return ui.selection
+}
+
+// SelectionCount returns the number of selected units of the given type, or of all types if
+// unitType is zero.
+func (ui *UIState) SelectionCount(unitType api.UnitTypeID) int {
+	if unitType == 0 {
+		return len(ui.selection)
+	}
+	n := 0
+	for _, u := range ui.selection {
+		if u.UnitType == unitType {
+			n++
+		}
+	}
+	return n
+}
+
+// SingleSelection returns the single unit panel, or nil if a single unit isn't selected.
+func (ui *UIState) SingleSelection() *api.SinglePanel {
+	return ui.ui.GetSingle()
+}
+
+// Cargo returns the cargo panel of the selected transport, or nil.
+func (ui *UIState) Cargo() *api.CargoPanel {
+	return ui.ui.GetCargo()
+}
+
+// Production returns the production panel of the selected building, or nil.
+func (ui *UIState) Production() *api.ProductionPanel {
+	return ui.ui.GetProduction()
+}
+
+// ProductionQueue returns the items queued in the selected building.
+func (ui *UIState) ProductionQueue() []*api.BuildItem {
+	return ui.ui.GetProduction().GetProductionQueue()
+}
+
+// ProductionProgress returns the progress (0-1) of the item currently being produced by the
+// selected building, or -1 if nothing is being produced.
+func (ui *UIState) ProductionProgress() float32 {
+	if queue := ui.ProductionQueue(); len(queue) > 0 {
+		return queue[0].BuildProgress
+	}
+	return -1
+}
+
+// Group returns the current contents of a control group (0-9).
+func (ui *UIState) Group(index uint32) api.ControlGroup {
+	if index >= NumControlGroups {
+		return api.ControlGroup{ControlGroupIndex: index}
+	}
+	return ui.groups[index]
+}
+
+// GroupChangedAt returns the game loop when a control group last changed.
+func (ui *UIState) GroupChangedAt(index uint32) uint32 {
+	if index >= NumControlGroups {
+		return 0
+	}
+	return ui.groupsChanged[index]
+}
+
+func (ui *UIState) update() {
+	obs := ui.info.Observation().GetObservation()
+	prevPanel, prevSelection, prevProduction := ui.panel, ui.selection, ui.Production()
+
+	ui.ui = obs.GetUiData()
+	ui.panel, ui.selection = PanelNone, nil
+	switch panel := ui.ui.GetPanel().(type) {
+	case *api.ObservationUI_Single:
+		ui.panel, ui.selection = PanelSingle, []*api.UnitInfo{panel.Single.GetUnit()}
+	case *api.ObservationUI_Multi:
+		ui.panel, ui.selection = PanelMulti, panel.Multi.GetUnits()
+	case *api.ObservationUI_Cargo:
+		ui.panel, ui.selection = PanelCargo, []*api.UnitInfo{panel.Cargo.GetUnit()}
+	case *api.ObservationUI_Production:
+		ui.panel, ui.selection = PanelProduction, []*api.UnitInfo{panel.Production.GetUnit()}
+	}
+
+	if ui.panel != prevPanel || !sameUnitTypes(ui.selection, prevSelection) {
+		for _, handler := range ui.selectionChanged {
+			handler()
+		}
+	}
+
+	var groups [NumControlGroups]api.ControlGroup
+	for i := range groups {
+		groups[i].ControlGroupIndex = uint32(i)
+	}
+	for _, g := range ui.ui.GetGroups() {
+		if g.ControlGroupIndex < NumControlGroups {
+			groups[g.ControlGroupIndex] = *g
+		}
+	}
+	for i, g := range groups {
+		if g != ui.groups[i] {
+			ui.groups[i] = g
+			ui.groupsChanged[i] = obs.GetGameLoop()
+			for _, handler := range ui.groupChanged {
+				handler(g)
+			}
+		}
+	}
+
+	if ui.panel != prevPanel || !sameProduction(ui.Production(), prevProduction) {
+		if ui.panel == PanelProduction || prevPanel == PanelProduction {
+			queue := ui.ProductionQueue()
+			for _, handler := range ui.productionChanged {
+				handler(queue)
+			}
+		}
+	}
+}
+
+func sameUnitTypes(a, b []*api.UnitInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].GetUnitType() != b[i].GetUnitType() {
+			return false
+		}
+	}
+	return true
+}
+
+// sameProduction returns false if cur shows a different building or queue than prev. An item
+// whose progress went backwards means one finished and the next identical one started.
+func sameProduction(cur, prev *api.ProductionPanel) bool {
+	a, b := cur.GetUnit(), prev.GetUnit()
+	if a.GetUnitType() != b.GetUnitType() || a.GetPlayerRelative() != b.GetPlayerRelative() ||
+		a.GetAddOn().GetUnitType() != b.GetAddOn().GetUnitType() {
+		return false
+	}
+
+	queue, prevQueue := cur.GetProductionQueue(), prev.GetProductionQueue()
+	if len(queue) != len(prevQueue) {
+		return false
+	}
+	for i := range queue {
+		if queue[i].GetAbilityId() != prevQueue[i].GetAbilityId() ||
+			queue[i].GetBuildProgress() < prevQueue[i].GetBuildProgress() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package botutil_test
+
+import (
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/botutil"
+	"github.com/chippydip/go-sc2ai/enums/ability"
+	"github.com/chippydip/go-sc2ai/enums/terran"
+)
+
+func (i *eventsInfo) observeUI(loop uint32, ui *api.ObservationUI) {
+	i.obs = &api.ResponseObservation{
+		Observation: &api.Observation{GameLoop: loop, UiData: ui},
+	}
+	if i.update != nil {
+		i.update()
+	}
+}
+
+func TestUIState(t *testing.T) {
+	i := &eventsInfo{}
+	i.observeUI(0, &api.ObservationUI{})
+
+	ui := botutil.NewUIState(i)
+	var selections, productions int
+	var groups []api.ControlGroup
+	ui.OnSelectionChanged(func() { selections++ })
+	ui.OnProductionChanged(func(queue []*api.BuildItem) { productions++ })
+	ui.OnControlGroupChanged(func(g api.ControlGroup) { groups = append(groups, g) })
+
+	barracks := &api.UnitInfo{UnitType: terran.Barracks}
+	i.observeUI(10, &api.ObservationUI{
+		Groups: []*api.ControlGroup{{ControlGroupIndex: 2, LeaderUnitType: terran.Barracks, Count: 1}},
+		Panel: &api.ObservationUI_Production{Production: &api.ProductionPanel{
+			Unit:            barracks,
+			ProductionQueue: []*api.BuildItem{{AbilityId: ability.Train_Marine, BuildProgress: 0.25}},
+		}},
+	})
+
+	if ui.Panel() != botutil.PanelProduction || ui.SelectionCount(terran.Barracks) != 1 {
+		t.Errorf("Panel = %v, Selection = %v", ui.Panel(), ui.Selection())
+	}
+	if p := ui.ProductionProgress(); p != 0.25 {
+		t.Errorf("ProductionProgress = %v, want 0.25", p)
+	}
+	if g := ui.Group(2); g.Count != 1 || ui.GroupChangedAt(2) != 10 {
+		t.Errorf("Group(2) = %v changed at %v", g, ui.GroupChangedAt(2))
+	}
+	if selections != 1 || productions != 1 || len(groups) != 1 {
+		t.Errorf("got %v selection, %v production and %v group changes", selections, productions, len(groups))
+	}
+
+	// Only progress changed
+	i.observeUI(20, &api.ObservationUI{
+		Groups: []*api.ControlGroup{{ControlGroupIndex: 2, LeaderUnitType: terran.Barracks, Count: 1}},
+		Panel: &api.ObservationUI_Production{Production: &api.ProductionPanel{
+			Unit:            barracks,
+			ProductionQueue: []*api.BuildItem{{AbilityId: ability.Train_Marine, BuildProgress: 0.5}},
+		}},
+	})
+	if selections != 1 || productions != 1 || len(groups) != 1 {
+		t.Errorf("got %v selection, %v production and %v group changes", selections, productions, len(groups))
+	}
+
+	i.observeUI(30, &api.ObservationUI{})
+	if ui.Panel() != botutil.PanelNone || selections != 2 || productions != 2 || len(groups) != 2 {
+		t.Errorf("got %v selection, %v production and %v group changes", selections, productions, len(groups))
+	}
+}
+
+func TestUIStateProductionQueue(t *testing.T) {
+	i := &eventsInfo{}
+	i.observeUI(0, &api.ObservationUI{})
+
+	ui := botutil.NewUIState(i)
+	productions := 0
+	ui.OnProductionChanged(func(queue []*api.BuildItem) { productions++ })
+
+	produce := func(loop uint32, building *api.UnitInfo, progress ...float32) {
+		var queue []*api.BuildItem
+		for _, p := range progress {
+			queue = append(queue, &api.BuildItem{AbilityId: ability.Train_Marine, BuildProgress: p})
+		}
+		i.observeUI(loop, &api.ObservationUI{
+			Panel: &api.ObservationUI_Production{Production: &api.ProductionPanel{Unit: building, ProductionQueue: queue}},
+		})
+	}
+
+	barracks := &api.UnitInfo{UnitType: terran.Barracks}
+	produce(10, barracks, 0.9, 0)
+	produce(20, barracks, 0.95, 0)
+	if productions != 1 {
+		t.Errorf("got %v production changes after progress, want 1", productions)
+	}
+
+	// The first marine finished and another one was queued
+	produce(30, barracks, 0.05, 0)
+	if productions != 2 {
+		t.Errorf("got %v production changes after a marine finished, want 2", productions)
+	}
+
+	// A different barracks with the same queue
+	reactor := &api.UnitInfo{UnitType: terran.Barracks, AddOn: &api.UnitInfo{UnitType: terran.BarracksReactor}}
+	produce(40, reactor, 0.1, 0)
+	if productions != 3 {
+		t.Errorf("got %v production changes after selecting another barracks, want 3", productions)
+	}
+}
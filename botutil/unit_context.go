@@ -1,7 +1,7 @@
 package botutil
 
 import (
-	"log"
+	"fmt"
 
 	"github.com/chippydip/go-sc2ai/api"
 	"github.com/chippydip/go-sc2ai/client"
@@ -106,9 +106,14 @@ func (ctx *UnitContext) update(info client.AgentInfo) {
 			UnitTag: u.Tag,
 		}
 	}
-	available := info.Query(api.RequestQuery{Abilities: query})
+	available, err := info.Query(api.RequestQuery{Abilities: query})
+	if err != nil {
+		info.Logger().Error("Failed to query abilities", "err", err)
+		panic(fmt.Sprintf("Failed to query abilities: %v", err))
+	}
 	if len(available.Abilities) != len(ctx.raw) {
-		log.Panicf("Missing ability responses, expected: %v got: %v", len(ctx.raw), len(available.Abilities))
+		info.Logger().Error("Missing ability responses", "expected", len(ctx.raw), "got", len(available.Abilities))
+		panic(fmt.Sprintf("Missing ability responses, expected: %v got: %v", len(ctx.raw), len(available.Abilities)))
 	}
 
 	// Allocate a new array for wrapped unit objects
@@ -1,7 +1,8 @@
 package client
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -36,20 +37,41 @@ type AgentInfo interface {
 
 	IsInGame() bool
 	Step(stepSize int) error
-
-	Query(query api.RequestQuery) *api.ResponseQuery
-	SendActions(actions []*api.Action) []api.ActionResult
-	SendObserverActions(obsActions []*api.ObserverAction)
-	SendDebugCommands(commands []*api.DebugCommand)
+	StepContext(ctx context.Context, stepSize int) error
+	StepAsync(stepSize int) *StepFuture
+	StepAsyncContext(ctx context.Context, stepSize int) *StepFuture
+	AutoStep() error
+	SetStepPolicy(policy StepPolicy)
+	WakeAt(gameLoop uint32)
+
+	Query(query api.RequestQuery) (*api.ResponseQuery, error)
+	QueryContext(ctx context.Context, query api.RequestQuery) (*api.ResponseQuery, error)
+	QueryAsync(query api.RequestQuery) *QueryFuture
+	QueryAsyncContext(ctx context.Context, query api.RequestQuery) *QueryFuture
+	SendActions(actions []*api.Action) ([]api.ActionResult, error)
+	SendActionsContext(ctx context.Context, actions []*api.Action) ([]api.ActionResult, error)
+	SendObserverActions(obsActions []*api.ObserverAction) error
+	SendDebugCommands(commands []*api.DebugCommand) error
+	SendDebugCommandsContext(ctx context.Context, commands []*api.DebugCommand) error
 	ClearDebugDraw()
-	LeaveGame()
-	SaveReplay(path string)
+	LeaveGame() error
+	SaveReplay(path string) error
+	QuickSave() error
+	QuickLoad() error
+	RestartGame() error
+	MapCommand(triggerCmd string) error
 
 	OnBeforeStep(func())
 	OnObservation(func())
 	OnAfterStep(func())
+	OnGameEnd(func())
 
 	SetPerfInterval(steps uint32)
+	SetStepBudget(budget StepBudget)
+	ResetStepTimer()
+
+	Logger() *slog.Logger
+	SetLogger(logger *slog.Logger)
 }
 
 // IsRealtime returns true if the bot was launched in realtime mode.
@@ -94,48 +116,62 @@ func (c *Client) HasUpgrade(upgrade api.UpgradeID) bool {
 }
 
 // Query ...
-func (c *Client) Query(query api.RequestQuery) *api.ResponseQuery {
-	resp, err := c.connection.query(query)
-	if err != nil {
-		log.Print(err)
-		return nil
-	}
-	return resp
+func (c *Client) Query(query api.RequestQuery) (*api.ResponseQuery, error) {
+	return c.QueryContext(context.Background(), query)
+}
+
+// QueryContext is like Query but aborts the request if ctx is done first. The connection to
+// the game is closed if the request is interrupted while it is in flight.
+func (c *Client) QueryContext(ctx context.Context, query api.RequestQuery) (*api.ResponseQuery, error) {
+	return c.connection.query(ctx, query)
 }
 
 // SendActions ...
-func (c *Client) SendActions(actions []*api.Action) []api.ActionResult {
+func (c *Client) SendActions(actions []*api.Action) ([]api.ActionResult, error) {
+	return c.SendActionsContext(context.Background(), actions)
+}
+
+// SendActionsContext is like SendActions but aborts the request if ctx is done first.
+func (c *Client) SendActionsContext(ctx context.Context, actions []*api.Action) ([]api.ActionResult, error) {
 	c.actions += len(actions)
 
 	if c.replayInfo != nil {
-		return nil // ignore actions in a replay
+		return nil, nil // ignore actions in a replay
 	}
 
-	resp, err := c.connection.action(api.RequestAction{
+	resp, err := c.connection.action(ctx, api.RequestAction{
 		Actions: actions,
 	})
 	if err != nil {
-		log.Print(err)
-		return nil
+		return nil, err
+	}
+	if c.connection.metrics != nil {
+		c.connection.metrics.ObserveActions(resp.GetResult())
 	}
-	return resp.GetResult()
+	return resp.GetResult(), nil
 }
 
 // SendObserverActions ...
-func (c *Client) SendObserverActions(obsActions []*api.ObserverAction) {
+func (c *Client) SendObserverActions(obsActions []*api.ObserverAction) error {
 	c.observerActions += len(obsActions)
 
-	if c.replayInfo == nil {
-		return // ignore observer actions in a normal game
+	if c.replayInfo == nil && !c.observer {
+		return nil // ignore observer actions when playing a game
 	}
 
-	c.connection.obsAction(api.RequestObserverAction{
+	_, err := c.connection.obsAction(context.Background(), api.RequestObserverAction{
 		Actions: obsActions,
 	})
+	return err
 }
 
 // SendDebugCommands ...
-func (c *Client) SendDebugCommands(commands []*api.DebugCommand) {
+func (c *Client) SendDebugCommands(commands []*api.DebugCommand) error {
+	return c.SendDebugCommandsContext(context.Background(), commands)
+}
+
+// SendDebugCommandsContext is like SendDebugCommands but aborts the request if ctx is done first.
+func (c *Client) SendDebugCommandsContext(ctx context.Context, commands []*api.DebugCommand) error {
 	c.debugCommands += len(commands)
 
 	c.lastDraw = nil
@@ -148,9 +184,10 @@ func (c *Client) SendDebugCommands(commands []*api.DebugCommand) {
 		c.debugDraw = deferCleanup(func() { c.ClearDebugDraw() })
 	}
 
-	c.connection.debug(api.RequestDebug{
+	_, err := c.connection.debug(ctx, api.RequestDebug{
 		Debug: commands,
 	})
+	return err
 }
 
 func deferCleanup(cleanup func()) chan struct{} {
@@ -188,21 +225,67 @@ func (c *Client) ClearDebugDraw() {
 }
 
 // LeaveGame ...
-func (c *Client) LeaveGame() {
-	c.connection.leaveGame(api.RequestLeaveGame{})
+func (c *Client) LeaveGame() error {
+	_, err := c.connection.leaveGame(context.Background(), api.RequestLeaveGame{})
+	return err
 }
 
 // SaveReplay ...
-func (c *Client) SaveReplay(path string) {
-	responseSaveReplay, err := c.connection.saveReplay(api.RequestSaveReplay{})
+func (c *Client) SaveReplay(path string) error {
+	responseSaveReplay, err := c.connection.saveReplay(context.Background(), api.RequestSaveReplay{})
 	if err != nil {
-		log.Print(err)
-		return
+		return err
+	}
+	return os.WriteFile(path, responseSaveReplay.GetData(), 0644)
+}
+
+// QuickSave saves the current game state so it can be restored with QuickLoad. Only single
+// player games support saving.
+func (c *Client) QuickSave() error {
+	c.waitPendingStep()
+	_, err := c.connection.quickSave(context.Background(), api.RequestQuickSave{})
+	return err
+}
+
+// QuickLoad restores the game state saved by the last QuickSave. The observation is fetched
+// again and cached state (upgrades, debug draws and perf counters) is reset.
+func (c *Client) QuickLoad() error {
+	c.waitPendingStep()
+	if _, err := c.connection.quickLoad(context.Background(), api.RequestQuickLoad{}); err != nil {
+		return err
 	}
-	err = os.WriteFile(path, responseSaveReplay.GetData(), 0644)
+	return c.resetState(context.Background())
+}
+
+// RestartGame restarts a single player game from the beginning (without relaunching it). The
+// observation is fetched again and cached state is reset just like QuickLoad.
+func (c *Client) RestartGame() error {
+	c.waitPendingStep()
+	r, err := c.connection.restartGame(context.Background(), api.RequestRestartGame{})
+	if err != nil {
+		return err
+	}
+	if r.Error != api.ResponseRestartGame_nil {
+		return &GameError{RestartGame: r.Error, Details: r.GetErrorDetails()}
+	}
+	if r.NeedHardReset {
+		return &GameError{Details: "game needs to be relaunched to restart"}
+	}
+	return c.resetState(context.Background())
+}
+
+// MapCommand runs a trigger command defined by the map.
+func (c *Client) MapCommand(triggerCmd string) error {
+	r, err := c.connection.mapCommand(context.Background(), api.RequestMapCommand{
+		TriggerCmd: triggerCmd,
+	})
 	if err != nil {
-		log.Print(err)
+		return err
+	}
+	if r.Error != api.ResponseMapCommand_nil {
+		return &GameError{MapCommand: r.Error, Details: r.GetErrorDetails()}
 	}
+	return nil
 }
 
 // OnBeforeStep ...
@@ -229,6 +312,22 @@ func (c *Client) OnAfterStep(callback func()) {
 	}
 }
 
+// OnGameEnd is called with the final observation (which contains the player results) when
+// a step finds the game is no longer in progress. The step and observation callbacks are not
+// called for that observation.
+func (c *Client) OnGameEnd(callback func()) {
+	if callback != nil {
+		c.gameEnd = append(c.gameEnd, callback)
+	}
+}
+
+// ClearCallbacks removes all step, observation and game end callbacks along with the step policy and
+// pending wake loops so another agent can be run on the same client (e.g. after RestartGame).
+func (c *Client) ClearCallbacks() {
+	c.beforeStep, c.subStep, c.afterStep, c.gameEnd = nil, nil, nil, nil
+	c.stepPolicy, c.wakeLoops = nil, nil
+}
+
 // SetPerfInterval determines how often perfornace data will be updated. Values
 // less than or equal to 0 will disable display (defalts to zero).
 func (c *Client) SetPerfInterval(steps uint32) {
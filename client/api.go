@@ -2,11 +2,13 @@
 package client
 
 import (
+	"context"
+
 	"github.com/chippydip/go-sc2ai/api"
 )
 
-func (c *connection) createGame(createGame api.RequestCreateGame) (*api.ResponseCreateGame, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) createGame(ctx context.Context, createGame api.RequestCreateGame) (*api.ResponseCreateGame, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_CreateGame{
 			CreateGame: &createGame,
 		},
@@ -14,8 +16,8 @@ func (c *connection) createGame(createGame api.RequestCreateGame) (*api.Response
 	return r.GetCreateGame(), err
 }
 
-func (c *connection) joinGame(joinGame api.RequestJoinGame) (*api.ResponseJoinGame, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) joinGame(ctx context.Context, joinGame api.RequestJoinGame) (*api.ResponseJoinGame, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_JoinGame{
 			JoinGame: &joinGame,
 		},
@@ -23,8 +25,8 @@ func (c *connection) joinGame(joinGame api.RequestJoinGame) (*api.ResponseJoinGa
 	return r.GetJoinGame(), err
 }
 
-func (c *connection) restartGame(restartGame api.RequestRestartGame) (*api.ResponseRestartGame, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) restartGame(ctx context.Context, restartGame api.RequestRestartGame) (*api.ResponseRestartGame, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_RestartGame{
 			RestartGame: &restartGame,
 		},
@@ -32,8 +34,8 @@ func (c *connection) restartGame(restartGame api.RequestRestartGame) (*api.Respo
 	return r.GetRestartGame(), err
 }
 
-func (c *connection) startReplay(startReplay api.RequestStartReplay) (*api.ResponseStartReplay, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) startReplay(ctx context.Context, startReplay api.RequestStartReplay) (*api.ResponseStartReplay, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_StartReplay{
 			StartReplay: &startReplay,
 		},
@@ -41,8 +43,8 @@ func (c *connection) startReplay(startReplay api.RequestStartReplay) (*api.Respo
 	return r.GetStartReplay(), err
 }
 
-func (c *connection) leaveGame(leaveGame api.RequestLeaveGame) (*api.ResponseLeaveGame, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) leaveGame(ctx context.Context, leaveGame api.RequestLeaveGame) (*api.ResponseLeaveGame, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_LeaveGame{
 			LeaveGame: &leaveGame,
 		},
@@ -50,8 +52,8 @@ func (c *connection) leaveGame(leaveGame api.RequestLeaveGame) (*api.ResponseLea
 	return r.GetLeaveGame(), err
 }
 
-func (c *connection) quickSave(quickSave api.RequestQuickSave) (*api.ResponseQuickSave, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) quickSave(ctx context.Context, quickSave api.RequestQuickSave) (*api.ResponseQuickSave, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_QuickSave{
 			QuickSave: &quickSave,
 		},
@@ -59,8 +61,8 @@ func (c *connection) quickSave(quickSave api.RequestQuickSave) (*api.ResponseQui
 	return r.GetQuickSave(), err
 }
 
-func (c *connection) quickLoad(quickLoad api.RequestQuickLoad) (*api.ResponseQuickLoad, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) quickLoad(ctx context.Context, quickLoad api.RequestQuickLoad) (*api.ResponseQuickLoad, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_QuickLoad{
 			QuickLoad: &quickLoad,
 		},
@@ -68,8 +70,8 @@ func (c *connection) quickLoad(quickLoad api.RequestQuickLoad) (*api.ResponseQui
 	return r.GetQuickLoad(), err
 }
 
-func (c *connection) quit(quit api.RequestQuit) (*api.ResponseQuit, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) quit(ctx context.Context, quit api.RequestQuit) (*api.ResponseQuit, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_Quit{
 			Quit: &quit,
 		},
@@ -77,8 +79,8 @@ func (c *connection) quit(quit api.RequestQuit) (*api.ResponseQuit, error) {
 	return r.GetQuit(), err
 }
 
-func (c *connection) gameInfo(gameInfo api.RequestGameInfo) (*api.ResponseGameInfo, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) gameInfo(ctx context.Context, gameInfo api.RequestGameInfo) (*api.ResponseGameInfo, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_GameInfo{
 			GameInfo: &gameInfo,
 		},
@@ -86,8 +88,8 @@ func (c *connection) gameInfo(gameInfo api.RequestGameInfo) (*api.ResponseGameIn
 	return r.GetGameInfo(), err
 }
 
-func (c *connection) observation(observation api.RequestObservation) (*api.ResponseObservation, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) observation(ctx context.Context, observation api.RequestObservation) (*api.ResponseObservation, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_Observation{
 			Observation: &observation,
 		},
@@ -95,8 +97,8 @@ func (c *connection) observation(observation api.RequestObservation) (*api.Respo
 	return r.GetObservation(), err
 }
 
-func (c *connection) action(action api.RequestAction) (*api.ResponseAction, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) action(ctx context.Context, action api.RequestAction) (*api.ResponseAction, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_Action{
 			Action: &action,
 		},
@@ -104,8 +106,8 @@ func (c *connection) action(action api.RequestAction) (*api.ResponseAction, erro
 	return r.GetAction(), err
 }
 
-func (c *connection) obsAction(obsAction api.RequestObserverAction) (*api.ResponseObserverAction, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) obsAction(ctx context.Context, obsAction api.RequestObserverAction) (*api.ResponseObserverAction, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_ObsAction{
 			ObsAction: &obsAction,
 		},
@@ -113,8 +115,8 @@ func (c *connection) obsAction(obsAction api.RequestObserverAction) (*api.Respon
 	return r.GetObsAction(), err
 }
 
-func (c *connection) step(step api.RequestStep) (*api.ResponseStep, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) step(ctx context.Context, step api.RequestStep) (*api.ResponseStep, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_Step{
 			Step: &step,
 		},
@@ -122,8 +124,8 @@ func (c *connection) step(step api.RequestStep) (*api.ResponseStep, error) {
 	return r.GetStep(), err
 }
 
-func (c *connection) data(data api.RequestData) (*api.ResponseData, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) data(ctx context.Context, data api.RequestData) (*api.ResponseData, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_Data{
 			Data: &data,
 		},
@@ -131,8 +133,8 @@ func (c *connection) data(data api.RequestData) (*api.ResponseData, error) {
 	return r.GetData(), err
 }
 
-func (c *connection) query(query api.RequestQuery) (*api.ResponseQuery, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) query(ctx context.Context, query api.RequestQuery) (*api.ResponseQuery, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_Query{
 			Query: &query,
 		},
@@ -140,8 +142,8 @@ func (c *connection) query(query api.RequestQuery) (*api.ResponseQuery, error) {
 	return r.GetQuery(), err
 }
 
-func (c *connection) saveReplay(saveReplay api.RequestSaveReplay) (*api.ResponseSaveReplay, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) saveReplay(ctx context.Context, saveReplay api.RequestSaveReplay) (*api.ResponseSaveReplay, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_SaveReplay{
 			SaveReplay: &saveReplay,
 		},
@@ -149,8 +151,8 @@ func (c *connection) saveReplay(saveReplay api.RequestSaveReplay) (*api.Response
 	return r.GetSaveReplay(), err
 }
 
-func (c *connection) mapCommand(mapCommand api.RequestMapCommand) (*api.ResponseMapCommand, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) mapCommand(ctx context.Context, mapCommand api.RequestMapCommand) (*api.ResponseMapCommand, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_MapCommand{
 			MapCommand: &mapCommand,
 		},
@@ -158,8 +160,8 @@ func (c *connection) mapCommand(mapCommand api.RequestMapCommand) (*api.Response
 	return r.GetMapCommand(), err
 }
 
-func (c *connection) replayInfo(replayInfo api.RequestReplayInfo) (*api.ResponseReplayInfo, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) replayInfo(ctx context.Context, replayInfo api.RequestReplayInfo) (*api.ResponseReplayInfo, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_ReplayInfo{
 			ReplayInfo: &replayInfo,
 		},
@@ -167,8 +169,8 @@ func (c *connection) replayInfo(replayInfo api.RequestReplayInfo) (*api.Response
 	return r.GetReplayInfo(), err
 }
 
-func (c *connection) availableMaps(availableMaps api.RequestAvailableMaps) (*api.ResponseAvailableMaps, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) availableMaps(ctx context.Context, availableMaps api.RequestAvailableMaps) (*api.ResponseAvailableMaps, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_AvailableMaps{
 			AvailableMaps: &availableMaps,
 		},
@@ -176,8 +178,8 @@ func (c *connection) availableMaps(availableMaps api.RequestAvailableMaps) (*api
 	return r.GetAvailableMaps(), err
 }
 
-func (c *connection) saveMap(saveMap api.RequestSaveMap) (*api.ResponseSaveMap, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) saveMap(ctx context.Context, saveMap api.RequestSaveMap) (*api.ResponseSaveMap, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_SaveMap{
 			SaveMap: &saveMap,
 		},
@@ -185,8 +187,8 @@ func (c *connection) saveMap(saveMap api.RequestSaveMap) (*api.ResponseSaveMap,
 	return r.GetSaveMap(), err
 }
 
-func (c *connection) ping(ping api.RequestPing) (*api.ResponsePing, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) ping(ctx context.Context, ping api.RequestPing) (*api.ResponsePing, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_Ping{
 			Ping: &ping,
 		},
@@ -194,8 +196,8 @@ func (c *connection) ping(ping api.RequestPing) (*api.ResponsePing, error) {
 	return r.GetPing(), err
 }
 
-func (c *connection) debug(debug api.RequestDebug) (*api.ResponseDebug, error) {
-	r, err := c.request(&api.Request{
+func (c *connection) debug(ctx context.Context, debug api.RequestDebug) (*api.ResponseDebug, error) {
+	r, err := c.request(ctx, &api.Request{
 		Request: &api.Request_Debug{
 			Debug: &debug,
 		},
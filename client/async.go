@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+)
+
+// StepFuture is the pending result of StepAsync.
+type StepFuture struct {
+	c      *Client
+	ctx    context.Context
+	target uint32
+	start  time.Time
+
+	step *call
+	obs  *call
+
+	waited bool
+	err    error
+}
+
+// StepAsync starts stepping the game forward without waiting for it to finish. Before-step
+// callbacks are run immediately, but the new observation is not processed (and observation
+// and after-step callbacks are not called) until Wait is called on the returned future.
+// This allows the agent to keep working while the game advances, which is mostly useful in
+// realtime mode. Starting another step first waits for any outstanding one to complete.
+func (c *Client) StepAsync(stepSize int) *StepFuture {
+	return c.StepAsyncContext(context.Background(), stepSize)
+}
+
+// StepAsyncContext is like StepAsync but aborts any outstanding requests if ctx is done first.
+func (c *Client) StepAsyncContext(ctx context.Context, stepSize int) *StepFuture {
+	// Only one step may be outstanding at a time
+	c.waitPendingStep()
+
+	f := &StepFuture{c: c, ctx: ctx}
+	c.pendingStep = f
+
+	if c.stepCheck != nil {
+		if err := c.stepCheck(); err != nil {
+			f.obs = finishedCall(ctx, "Observation", err)
+			return f
+		}
+	}
+
+	// Time spent by the agent since the last step finished
+	t := time.Now()
+	if !c.stepEnd.IsZero() {
+		c.observePhase("agent", t.Sub(c.stepEnd))
+	}
+
+	// Call before callbacks
+	for _, cb := range c.beforeStep {
+		cb()
+	}
+	d := time.Since(t)
+	c.beforeStepTime += d
+	c.observePhase("beforeStep", d)
+
+	// Don't skip past any game loop the agent asked to be woken at
+	stepSize = c.clampStep(stepSize)
+
+	// Check how long the agent took before asking for the next step
+	if !c.observationEnd.IsZero() {
+		elapsed := time.Since(c.observationEnd)
+		simulated := c.simulateRealtime(stepSize, elapsed)
+
+		extra, err := c.enforceBudget(elapsed)
+		if err != nil {
+			f.obs = finishedCall(ctx, "Observation", err)
+			return f
+		}
+
+		// Both charge for the same elapsed time, so the budget only adds loops beyond
+		// what simulated realtime already skipped
+		stepSize += int(extra)
+		if simulated > stepSize {
+			stepSize = simulated
+		}
+	}
+
+	// Step the simulation forward if this isn't in realtime mode and queue up the
+	// observation request behind it rather than waiting in between.
+	f.start = time.Now()
+	if !c.realtime && stepSize > 0 {
+		f.step = c.connection.send(ctx, &api.Request{
+			Request: &api.Request_Step{
+				Step: &api.RequestStep{
+					Count: uint32(stepSize),
+				},
+			},
+		})
+	}
+
+	f.target = c.observation.GetObservation().GetGameLoop() + uint32(stepSize)
+	f.obs = c.connection.send(ctx, observationRequest(f.target))
+	return f
+}
+
+// Done is closed once the first observation for the step has been received. Wait must
+// still be called to process it.
+func (f *StepFuture) Done() <-chan struct{} {
+	return f.obs.done
+}
+
+// Wait blocks until the step is complete, then processes the new observation and runs the
+// observation and after-step callbacks on the calling goroutine. Calling Wait again just
+// returns the same result.
+func (f *StepFuture) Wait() error {
+	if !f.waited {
+		f.waited = true
+		f.err = f.c.finishStep(f)
+		if f.c.pendingStep == f {
+			f.c.pendingStep = nil
+		}
+	}
+	return f.err
+}
+
+func observationRequest(gameLoop uint32) *api.Request {
+	return &api.Request{
+		Request: &api.Request_Observation{
+			Observation: &api.RequestObservation{
+				GameLoop: gameLoop,
+			},
+		},
+	}
+}
+
+// QueryFuture is the pending result of QueryAsync.
+type QueryFuture struct {
+	cl *call
+}
+
+// QueryAsync sends a query without waiting for the result. Multiple queries may be
+// outstanding at once and they will be answered in the order they were sent.
+func (c *Client) QueryAsync(query api.RequestQuery) *QueryFuture {
+	return c.QueryAsyncContext(context.Background(), query)
+}
+
+// QueryAsyncContext is like QueryAsync but aborts the request if ctx is done first. The
+// connection to the game is closed if the request is interrupted while it is in flight.
+func (c *Client) QueryAsyncContext(ctx context.Context, query api.RequestQuery) *QueryFuture {
+	return &QueryFuture{c.connection.send(ctx, &api.Request{
+		Request: &api.Request_Query{
+			Query: &query,
+		},
+	})}
+}
+
+// Done is closed once the response has been received.
+func (f *QueryFuture) Done() <-chan struct{} {
+	return f.cl.done
+}
+
+// Wait blocks until the response has been received and returns it. It may be called from any
+// goroutine, so unlike other requests it doesn't update the connection status.
+func (f *QueryFuture) Wait() (*api.ResponseQuery, error) {
+	r, err := f.cl.wait()
+	return r.GetQuery(), err
+}
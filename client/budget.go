@@ -0,0 +1,162 @@
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// BudgetPolicy is what the step-time watchdog does when the agent exceeds its budget.
+type BudgetPolicy int
+
+const (
+	// BudgetLog only logs an error.
+	BudgetLog BudgetPolicy = iota
+	// BudgetSkip logs an error and advances the game by the extra number of game loops that
+	// would have passed in realtime, so the agent experiences the consequences of being slow
+	// without actually being disqualified. It is the same as BudgetLog in realtime mode. With
+	// SetSimulatedRealtime the loops skipped for the same time aren't skipped twice.
+	BudgetSkip
+	// BudgetLeave logs an error and leaves the game, simulating a disqualification.
+	BudgetLeave
+)
+
+// LoopDuration is the realtime duration of a single game loop at "faster" game speed.
+const LoopDuration = time.Second * 10 / 224
+
+// StepBudget configures the step-time watchdog. The time the agent spends on each step is
+// measured from when the observation is received until the next step is requested (so it
+// includes after-step and before-step callbacks). Zero values disable each check.
+type StepBudget struct {
+	// PerStep is the most time the agent may spend on a single step.
+	PerStep time.Duration
+
+	// Window and WindowLimit limit the total time spent over the last Window steps.
+	Window      int
+	WindowLimit time.Duration
+
+	// TimeBank is extra time that PerStep overruns are drawn from before they count against
+	// the agent. BankRefill is added back to the bank after every step (up to TimeBank).
+	TimeBank   time.Duration
+	BankRefill time.Duration
+
+	// WarnAt is the fraction (0-1) of a limit at which warnings are logged, the default is 0.8.
+	WarnAt float64
+
+	// Policy is applied whenever a limit is exceeded.
+	Policy BudgetPolicy
+}
+
+// BudgetError is returned by Step when the agent exceeded its budget with BudgetLeave.
+type BudgetError struct {
+	Elapsed time.Duration
+	Limit   string
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("step time budget exceeded: %v (%v)", e.Elapsed, e.Limit)
+}
+
+// SetStepBudget starts enforcing a step-time budget, a zero StepBudget disables it.
+func (c *Client) SetStepBudget(budget StepBudget) {
+	c.budget = nil
+	if budget != (StepBudget{}) {
+		if budget.WarnAt == 0 {
+			budget.WarnAt = 0.8
+		}
+		c.budget = &budgetWatchdog{StepBudget: budget, bank: budget.TimeBank}
+	}
+}
+
+// budgetWatchdog tracks the agent's time usage against a StepBudget.
+type budgetWatchdog struct {
+	StepBudget
+
+	bank    time.Duration
+	history []time.Duration // ring buffer of the last Window steps
+	next    int
+	total   time.Duration
+}
+
+// check records the time spent on a step and returns how far over budget it was (if at all)
+// along with a description of the limit that was exceeded.
+func (w *budgetWatchdog) check(c *Client, elapsed time.Duration) (time.Duration, string) {
+	var over time.Duration
+	var limit string
+	log := c.Logger()
+
+	if w.PerStep > 0 {
+		if stepOver := elapsed - w.PerStep; stepOver > 0 {
+			if w.TimeBank > 0 {
+				w.bank -= stepOver
+				if w.bank < 0 {
+					over, limit = -w.bank, "time bank"
+					w.bank = 0
+				} else if w.bank < time.Duration(float64(w.TimeBank)*(1-w.WarnAt)) {
+					log.Warn("Time bank running low", "elapsed", elapsed, "bank", w.bank)
+				}
+			} else {
+				over, limit = stepOver, fmt.Sprintf("per step %v", w.PerStep)
+			}
+		} else if elapsed > time.Duration(float64(w.PerStep)*w.WarnAt) {
+			log.Warn("Step time close to budget", "elapsed", elapsed, "limit", w.PerStep)
+		}
+	}
+
+	if w.TimeBank > 0 {
+		if w.bank += w.BankRefill; w.bank > w.TimeBank {
+			w.bank = w.TimeBank
+		}
+	}
+
+	if w.Window > 0 && w.WindowLimit > 0 {
+		if len(w.history) < w.Window {
+			w.history = append(w.history, elapsed)
+		} else {
+			w.total -= w.history[w.next]
+			w.history[w.next] = elapsed
+			w.next = (w.next + 1) % w.Window
+		}
+		w.total += elapsed
+
+		if windowOver := w.total - w.WindowLimit; windowOver > 0 {
+			if windowOver > over {
+				over, limit = windowOver, fmt.Sprintf("%v over %v steps", w.WindowLimit, w.Window)
+			}
+		} else if w.total > time.Duration(float64(w.WindowLimit)*w.WarnAt) {
+			log.Warn("Step time close to window budget", "total", w.total, "limit", w.WindowLimit, "steps", w.Window)
+		}
+	}
+
+	if over > 0 {
+		log.Error("Step time budget exceeded", "elapsed", elapsed, "over", over, "limit", limit)
+	}
+	return over, limit
+}
+
+// enforceBudget checks the time the agent spent since the last observation and applies the
+// budget policy. It returns the number of extra game loops to step or an error if the
+// agent left the game.
+func (c *Client) enforceBudget(elapsed time.Duration) (uint32, error) {
+	if c.budget == nil {
+		return 0, nil
+	}
+
+	over, limit := c.budget.check(c, elapsed)
+	if over <= 0 {
+		return 0, nil
+	}
+
+	switch c.budget.Policy {
+	case BudgetSkip:
+		if !c.realtime {
+			return uint32((over + LoopDuration - 1) / LoopDuration), nil
+		}
+	case BudgetLeave:
+		err := &BudgetError{elapsed, limit}
+		if leaveErr := c.LeaveGame(); leaveErr != nil {
+			c.Logger().Error("Failed to leave game", "err", leaveErr)
+		}
+		return 0, err
+	}
+	return 0, nil
+}
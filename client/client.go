@@ -1,8 +1,9 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
@@ -23,9 +24,12 @@ type Client struct {
 	upgrades    map[api.UpgradeID]struct{}
 	newUpgrades []api.UpgradeID
 
+	pendingStep *StepFuture
+
 	beforeStep []func()
 	subStep    []func()
 	afterStep  []func()
+	gameEnd    []func()
 
 	debugDraw chan struct{}
 
@@ -38,6 +42,16 @@ type Client struct {
 	stepTime        time.Duration
 	observationTime time.Duration
 	afterStepTime   time.Duration
+	stepEnd         time.Time
+	observationEnd  time.Time
+
+	budget            *budgetWatchdog
+	simulatedRealtime bool
+	simulatedLoops    int
+	stepPolicy        StepPolicy
+	stepCheck         func() error
+	wakeLoops         []uint32
+	observer          bool
 
 	actions          int
 	maxActions       int
@@ -62,18 +76,17 @@ func (c *Client) Connect(address string, port int, timeout time.Duration) error
 		time.Sleep(time.Second)
 
 		if i == 0 {
-			fmt.Print("Waiting for connection")
+			c.Logger().Info("Waiting for connection", "address", address, "port", port)
 		} else {
-			fmt.Print(".")
+			c.Logger().Debug("Waiting for connection", "address", address, "port", port, "attempt", i+1)
 		}
 	}
-	fmt.Println()
 
 	if !connected {
 		return fmt.Errorf("Unable to connect to game")
 	}
 
-	log.Printf("Connected to %v:%v", address, port)
+	c.Logger().Info("Connected", "address", address, "port", port)
 	return nil
 }
 
@@ -83,15 +96,50 @@ func (c *Client) TryConnect(address string, port int) error {
 		return err
 	}
 
-	log.Printf("Connected to %v:%v", address, port)
+	c.Logger().Info("Connected", "address", address, "port", port)
 	return nil
 }
 
-// RemoteSaveMap(data []byte, remotePath string) error
+// ConnectTransport connects the client using an already open transport.
+func (c *Client) ConnectTransport(t Transport) error {
+	c.connection.Status = api.Status_unknown
+	return c.connection.attach(t)
+}
+
+// Close disconnects from the game without leaving it, e.g. so another program can connect
+// to the same game instance. Any pending requests fail.
+func (c *Client) Close() {
+	if c.connection.pipe != nil {
+		c.connection.pipe.fail(errors.New("connection closed by client"))
+	}
+}
+
+// RemoteSaveMap saves map data to remotePath on the machine running the game so it can be
+// used to create a game even if the game doesn't share a file system with the bot. Map files
+// are often larger than MaxMessageSize so it needs to be increased before connecting (the
+// runner does this automatically).
+func (c *Client) RemoteSaveMap(data []byte, remotePath string) error {
+	r, err := c.connection.saveMap(context.Background(), api.RequestSaveMap{
+		MapPath: remotePath,
+		MapData: data,
+	})
+	if err != nil {
+		return err
+	}
+	if r.Error != api.ResponseSaveMap_nil {
+		return &GameError{SaveMap: r.Error, Details: remotePath}
+	}
+	return nil
+}
 
 // CreateGame ...
 func (c *Client) CreateGame(mapPath string, players []*api.PlayerSetup, realtime bool) error {
-	r, err := c.connection.createGame(api.RequestCreateGame{
+	return c.CreateGameContext(context.Background(), mapPath, players, realtime)
+}
+
+// CreateGameContext is like CreateGame but aborts the request if ctx is done first.
+func (c *Client) CreateGameContext(ctx context.Context, mapPath string, players []*api.PlayerSetup, realtime bool) error {
+	return c.createGame(ctx, api.RequestCreateGame{
 		Map: &api.RequestCreateGame_LocalMap{
 			LocalMap: &api.LocalMap{
 				MapPath: mapPath,
@@ -100,13 +148,24 @@ func (c *Client) CreateGame(mapPath string, players []*api.PlayerSetup, realtime
 		PlayerSetup: players,
 		Realtime:    realtime,
 	})
+}
+
+// RequestCreateGame creates a game from a complete request. This allows other map sources
+// than a path on the game's file system, such as sending the map data along with the request
+// (LocalMap.MapData) or downloading a map by name (BattlenetMapName).
+func (c *Client) RequestCreateGame(request api.RequestCreateGame) error {
+	return c.createGame(context.Background(), request)
+}
+
+func (c *Client) createGame(ctx context.Context, request api.RequestCreateGame) error {
+	r, err := c.connection.createGame(ctx, request)
 	if err != nil {
 		return err
 	}
-	c.realtime = realtime
+	c.realtime = request.Realtime
 
 	if r.Error != api.ResponseCreateGame_nil {
-		return fmt.Errorf("%v: %v", r.Error, r.GetErrorDetails())
+		return &GameError{CreateGame: r.Error, Details: r.GetErrorDetails()}
 	}
 
 	return nil
@@ -114,33 +173,44 @@ func (c *Client) CreateGame(mapPath string, players []*api.PlayerSetup, realtime
 
 // RequestJoinGame ...
 func (c *Client) RequestJoinGame(setup *api.PlayerSetup, options *api.InterfaceOptions, ports Ports) error {
+	return c.RequestJoinGameContext(context.Background(), setup, options, ports)
+}
+
+// RequestJoinGameContext is like RequestJoinGame but aborts the request if ctx is done first.
+func (c *Client) RequestJoinGameContext(ctx context.Context, setup *api.PlayerSetup, options *api.InterfaceOptions, ports Ports) error {
 	req := api.RequestJoinGame{
 		Participation: &api.RequestJoinGame_Race{
 			Race: setup.Race,
 		},
 		Options: options,
 	}
+	if setup.Type == api.PlayerType_Observer {
+		// Zero observes every player rather than joining with a particular perspective
+		req.Participation = &api.RequestJoinGame_ObservedPlayerId{}
+	}
 	if ports.isValid() {
 		req.SharedPort = ports.SharedPort
 		req.ServerPorts = ports.ServerPorts
 		req.ClientPorts = ports.ClientPorts
 	}
-	r, err := c.connection.joinGame(req)
+	r, err := c.connection.joinGame(ctx, req)
 	if err != nil {
 		return err
 	}
 
 	if r.Error != api.ResponseJoinGame_nil {
-		return fmt.Errorf("%v: %v", r.Error.String(), r.GetErrorDetails())
+		return &GameError{JoinGame: r.Error, Details: r.GetErrorDetails()}
 	}
 
 	c.playerID = r.GetPlayerId()
+	c.observer = setup.Type == api.PlayerType_Observer
+	c.connection.game.playerID.Store(uint32(c.playerID))
 	return nil
 }
 
 // RequestReplayInfo ...
 func (c *Client) RequestReplayInfo(path string) (*api.ResponseReplayInfo, error) {
-	r, err := c.connection.replayInfo(api.RequestReplayInfo{
+	r, err := c.connection.replayInfo(context.Background(), api.RequestReplayInfo{
 		Replay: &api.RequestReplayInfo_ReplayPath{
 			ReplayPath: path,
 		},
@@ -150,7 +220,7 @@ func (c *Client) RequestReplayInfo(path string) (*api.ResponseReplayInfo, error)
 		return nil, err
 	}
 	if r.Error != api.ResponseReplayInfo_nil {
-		return nil, fmt.Errorf("%v: %v", r.Error.String(), r.GetErrorDetails())
+		return nil, &GameError{ReplayInfo: r.Error, Details: r.GetErrorDetails()}
 	}
 	return r, nil
 }
@@ -164,41 +234,47 @@ func (c *Client) Proto() api.ResponsePing {
 func (c *Client) RequestStartReplay(request api.RequestStartReplay) error {
 	c.replayInfo = nil
 
-	r, err := c.connection.startReplay(request)
+	r, err := c.connection.startReplay(context.Background(), request)
 	if err != nil {
 		return err
 	}
 	if r.Error != api.ResponseStartReplay_nil {
-		return fmt.Errorf("%v: %v", r.Error.String(), r.GetErrorDetails())
+		return &GameError{StartReplay: r.Error, Details: r.GetErrorDetails()}
 	}
 
 	c.replayInfo, err = c.RequestReplayInfo(request.GetReplayPath())
 	if err != nil {
-		log.Print(err)
+		c.Logger().Warn("Unable to get replay info", "err", err)
 	}
 	return nil
 }
 
 // RequestLeaveGame ...
 func (c *Client) RequestLeaveGame() error {
-	_, err := c.connection.leaveGame(api.RequestLeaveGame{})
+	_, err := c.connection.leaveGame(context.Background(), api.RequestLeaveGame{})
 	return err
 }
 
 // Init ...
 func (c *Client) Init() error {
+	return c.InitContext(context.Background())
+}
+
+// InitContext is like Init but aborts any outstanding requests if ctx is done first.
+func (c *Client) InitContext(ctx context.Context) error {
 	var infoErr, dataErr, obsErr error
 
 	// Fire off all three requests
-	c.gameInfo, infoErr = c.connection.gameInfo(api.RequestGameInfo{})
-	c.data, dataErr = c.connection.data(api.RequestData{
+	c.gameInfo, infoErr = c.connection.gameInfo(ctx, api.RequestGameInfo{})
+	c.data, dataErr = c.connection.data(ctx, api.RequestData{
 		AbilityId:  true,
 		UnitTypeId: true,
 		UpgradeId:  true,
 		BuffId:     true,
 		EffectId:   true,
 	})
-	c.observation, obsErr = c.connection.observation(api.RequestObservation{})
+	c.observation, obsErr = c.connection.observation(ctx, api.RequestObservation{})
+	c.connection.game.setObservation(c.observation)
 	c.upgrades = map[api.UpgradeID]struct{}{}
 
 	c.perfStart = time.Now()
@@ -219,33 +295,42 @@ func (c *Client) Init() error {
 
 // Step ...
 func (c *Client) Step(stepSize int) error {
-	var err error
+	return c.StepContext(context.Background(), stepSize)
+}
 
-	// Call before callbacks
-	t := time.Now()
-	for _, cb := range c.beforeStep {
-		cb()
-	}
-	c.beforeStepTime += time.Since(t)
+// StepContext is like Step but aborts any outstanding requests if ctx is done first. The
+// connection to the game is closed if a request is interrupted while it is in flight.
+func (c *Client) StepContext(ctx context.Context, stepSize int) error {
+	return c.StepAsyncContext(ctx, stepSize).Wait()
+}
 
-	// Step the simulation forward if this isn't in realtime mode
-	t = time.Now()
-	if !c.realtime && stepSize > 0 {
-		if _, err := c.connection.step(api.RequestStep{
-			Count: uint32(stepSize),
-		}); err != nil {
+// finishStep waits for the requests started by StepAsync and then processes the results.
+func (c *Client) finishStep(f *StepFuture) error {
+	var err error
+
+	// Wait for the simulation to step forward
+	if f.step != nil {
+		if _, err := c.connection.wait(f.step); err != nil {
 			return err
 		}
 	}
-	c.stepTime += time.Since(t)
+	var d time.Duration
+	if !f.start.IsZero() { // not set if the step was never sent
+		d = time.Since(f.start)
+		c.stepTime += d
+		c.observePhase("step", d)
+	}
 
 	// Get an updated observation
-	t = time.Now()
-	step := c.observation.GetObservation().GetGameLoop() + uint32(stepSize)
+	t := time.Now()
+	obs := f.obs
 	for {
-		if c.observation, err = c.connection.observation(api.RequestObservation{GameLoop: step}); err != nil {
+		var r *api.Response
+		if r, err = c.connection.wait(obs); err != nil {
 			return err
 		}
+		c.observation = r.GetObservation()
+		c.connection.game.setObservation(c.observation)
 
 		actionsCompleted := len(c.observation.GetActions())
 		c.actionsCompleted += actionsCompleted
@@ -256,6 +341,9 @@ func (c *Client) Step(stepSize int) error {
 		if !c.IsInGame() {
 			// Clear draw commands in case the game is left running
 			c.ClearDebugDraw()
+			for _, cb := range c.gameEnd {
+				cb()
+			}
 			return nil
 		}
 
@@ -264,11 +352,15 @@ func (c *Client) Step(stepSize int) error {
 			cb()
 		}
 
-		if c.observation.GetObservation().GetGameLoop() >= step {
+		if c.observation.GetObservation().GetGameLoop() >= f.target {
 			break
 		}
+		obs = c.connection.send(f.ctx, observationRequest(f.target))
 	}
-	c.observationTime += time.Since(t)
+	d = time.Since(t)
+	c.observationTime += d
+	c.observationEnd = time.Now()
+	c.observePhase("observation", d)
 
 	// Check for new upgrades
 	c.newUpgrades = nil
@@ -283,7 +375,7 @@ func (c *Client) Step(stepSize int) error {
 		// Re-fetch unit data since some of it is upgrade-dependent
 		// TODO: also (re-)fetch unit -> ability mapping?
 		var data *api.ResponseData
-		data, err = c.connection.data(api.RequestData{
+		data, err = c.connection.data(f.ctx, api.RequestData{
 			UnitTypeId: true,
 		})
 		c.data.Units = data.GetUnits()
@@ -294,7 +386,10 @@ func (c *Client) Step(stepSize int) error {
 	for _, cb := range c.afterStep {
 		cb()
 	}
-	c.afterStepTime += time.Since(t)
+	d = time.Since(t)
+	c.afterStepTime += d
+	c.observePhase("afterStep", d)
+	c.stepEnd = time.Now()
 
 	// Performance reporting (update every perfInterval game frames)
 	if c.perfInterval > 0 && c.observation.GetObservation().GetGameLoop()%c.perfInterval == 0 {
@@ -320,6 +415,7 @@ func (c *Client) reportPerf() {
 		fmt.Sprintf("maxActions:  %v\n", c.maxActions) +
 		fmt.Sprintf("obsActions:  %v\n", c.observerActions) +
 		fmt.Sprintf("debugCmds:   %v\n", c.debugCommands) +
+		fmt.Sprintf("simLoops:    %v\n", c.simulatedLoops) +
 		""
 	text = strings.Replace(text, "µ", "u", -1)
 
@@ -335,6 +431,7 @@ func (c *Client) reportPerf() {
 	c.actionsCompleted = 0
 	c.observerActions = 0
 	c.debugCommands = 0
+	c.simulatedLoops = 0
 
 	c.SendDebugCommands(append(c.lastDraw, &api.DebugCommand{
 		Command: &api.DebugCommand_Draw{
@@ -352,7 +449,59 @@ func (c *Client) reportPerf() {
 	c.lastDraw = c.lastDraw[:len(c.lastDraw)-1]
 }
 
-// SaveReplay(path string) error
+// resetState fetches a new observation and clears everything derived from earlier ones after
+// the game state has been replaced by QuickLoad or RestartGame.
+func (c *Client) resetState(ctx context.Context) error {
+	// Forget about previous upgrades and draw commands
+	c.upgrades = map[api.UpgradeID]struct{}{}
+	c.newUpgrades = nil
+	if c.debugDraw != nil {
+		c.ClearDebugDraw()
+	}
+	c.lastDraw = nil
+
+	observation, err := c.connection.observation(ctx, api.RequestObservation{})
+	if err != nil {
+		return err
+	}
+	c.observation = observation
+	c.connection.game.setObservation(c.observation)
+
+	// Unit data depends on upgrades so it needs to be refreshed as well
+	data, err := c.connection.data(ctx, api.RequestData{
+		UnitTypeId: true,
+	})
+	if err != nil {
+		return err
+	}
+	if c.data != nil {
+		c.data.Units = data.GetUnits()
+	}
+
+	// Restart perf counters
+	c.perfStart = time.Now()
+	c.perfStartFrame = c.observation.GetObservation().GetGameLoop()
+	c.beforeStepTime = 0
+	c.stepTime = 0
+	c.observationTime = 0
+	c.afterStepTime = 0
+	c.stepEnd = time.Time{}
+	c.observationEnd = time.Time{}
+	c.actions = 0
+	c.maxActions = 0
+	c.actionsCompleted = 0
+	c.observerActions = 0
+	c.debugCommands = 0
+	c.simulatedLoops = 0
+	return nil
+}
+
+// waitPendingStep finishes any step started by StepAsync before the game state is changed.
+func (c *Client) waitPendingStep() {
+	if c.pendingStep != nil {
+		c.pendingStep.Wait()
+	}
+}
 
 // Print() error
 
@@ -380,7 +529,7 @@ func (c *Client) IsInGame() bool {
 
 // GetObservation ...
 func (c *Client) GetObservation() (*api.ResponseObservation, error) {
-	return c.connection.observation(api.RequestObservation{})
+	return c.connection.observation(context.Background(), api.RequestObservation{})
 }
 
 // PollResponse() bool
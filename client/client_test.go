@@ -0,0 +1,570 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+	"github.com/chippydip/go-sc2ai/sc2test"
+)
+
+func TestConnectRetries(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	attempts := 0
+	c := &client.Client{}
+	c.Dialer = func(address string, port int) (client.Transport, error) {
+		if attempts++; attempts < 2 {
+			return nil, errors.New("not listening yet")
+		}
+		return client.DialWebsocket(address, port)
+	}
+	if err := c.Connect(s.Address(), s.Port(), 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %v, want 2", attempts)
+	}
+	if c.Status != api.Status_launched {
+		t.Errorf("Status = %v, want %v", c.Status, api.Status_launched)
+	}
+}
+
+func TestJoinGameError(t *testing.T) {
+	s := &sc2test.Server{
+		JoinGame: func(*api.RequestJoinGame) *api.ResponseJoinGame {
+			return &api.ResponseJoinGame{Error: api.ResponseJoinGame_GameFull, ErrorDetails: "full"}
+		},
+	}
+	s.Start()
+	defer s.Close()
+
+	c := sc2test.Connect(t, s)
+	err := c.RequestJoinGame(&api.PlayerSetup{Race: api.Race_Terran}, &api.InterfaceOptions{}, client.Ports{})
+
+	var gameErr *client.GameError
+	if !errors.As(err, &gameErr) || gameErr.JoinGame != api.ResponseJoinGame_GameFull {
+		t.Fatalf("err = %v, want GameFull", err)
+	}
+}
+
+func TestStepUntilGameEnd(t *testing.T) {
+	s := &sc2test.Server{EndLoop: 10}
+	s.Start()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	if !c.IsInGame() {
+		t.Fatalf("Status = %v, want in_game", c.Status)
+	}
+
+	steps := 0
+	for c.IsInGame() {
+		if err := c.Step(4); err != nil {
+			t.Fatal(err)
+		}
+		steps++
+	}
+
+	if steps != 3 || c.Status != api.Status_ended {
+		t.Errorf("steps = %v, Status = %v", steps, c.Status)
+	}
+	if got := c.Observation().GetObservation().GetGameLoop(); got != 10 {
+		t.Errorf("GameLoop = %v, want 10", got)
+	}
+	if len(c.Observation().GetPlayerResult()) != 2 {
+		t.Errorf("missing player results")
+	}
+}
+
+func TestProtocolError(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.Connect(t, s)
+	_, err := c.Query(api.RequestQuery{})
+
+	var protoErr *client.ProtocolError
+	if !errors.As(err, &protoErr) || protoErr.Request != "Query" {
+		t.Fatalf("err = %v, want ProtocolError", err)
+	}
+}
+
+func TestConnectionClosed(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	s.Disconnect()
+
+	var closedErr *client.ConnectionClosedError
+	if err := c.Step(1); !errors.As(err, &closedErr) {
+		t.Fatalf("err = %v, want ConnectionClosedError", err)
+	}
+	if _, err := c.Query(api.RequestQuery{}); !errors.As(err, &closedErr) {
+		t.Fatalf("err = %v, want ConnectionClosedError", err)
+	}
+}
+
+func TestStepContextCancel(t *testing.T) {
+	// The game hangs and never answers the step
+	hang := make(chan struct{})
+	s := &sc2test.Server{
+		Step: func(*api.RequestStep) *api.ResponseStep {
+			<-hang
+			return &api.ResponseStep{}
+		},
+	}
+	s.Start()
+	defer s.Close()
+	defer close(hang)
+
+	c := sc2test.NewGame(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.StepContext(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+
+	// The connection is closed so later requests fail instead of waiting behind the step
+	done := make(chan error)
+	go func() {
+		_, err := c.Query(api.RequestQuery{})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		var closed *client.ConnectionClosedError
+		if !errors.As(err, &closed) {
+			t.Errorf("Query after cancel: err = %v, want ConnectionClosedError", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Query after cancel is blocked")
+	}
+}
+
+func TestQueryAsyncCancel(t *testing.T) {
+	release := make(chan struct{})
+	s := &sc2test.Server{
+		Query: func(r *api.RequestQuery) *api.ResponseQuery {
+			<-release
+			return &api.ResponseQuery{}
+		},
+	}
+	s.Start()
+	defer s.Close()
+	defer close(release)
+
+	c := sc2test.NewGame(t, s)
+
+	path := &api.RequestQueryPathing{}
+	ctx, cancel := context.WithCancel(context.Background())
+	f1 := c.QueryAsyncContext(ctx, api.RequestQuery{Pathing: []*api.RequestQueryPathing{path}})
+	f2 := c.QueryAsync(api.RequestQuery{Pathing: []*api.RequestQueryPathing{path, path}})
+
+	cancel()
+	if _, err := f1.Wait(); err != context.Canceled {
+		t.Fatalf("f1 err = %v, want Canceled", err)
+	}
+
+	// Wait may be called from another goroutine, the other outstanding query fails too
+	done := make(chan error)
+	go func() {
+		_, err := f2.Wait()
+		done <- err
+	}()
+	var closed *client.ConnectionClosedError
+	if err := <-done; !errors.As(err, &closed) {
+		t.Fatalf("f2 err = %v, want ConnectionClosedError", err)
+	}
+	if err := c.Step(1); !errors.As(err, &closed) {
+		t.Fatalf("Step after cancel: err = %v, want ConnectionClosedError", err)
+	}
+}
+
+func TestQueryAsync(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+
+	path := &api.RequestQueryPathing{}
+	f1 := c.QueryAsync(api.RequestQuery{Pathing: []*api.RequestQueryPathing{path}})
+	f2 := c.QueryAsync(api.RequestQuery{Pathing: []*api.RequestQueryPathing{path, path}})
+
+	r2, err := f2.Wait()
+	if err != nil || len(r2.GetPathing()) != 2 {
+		t.Fatalf("f2 = %v, %v", r2, err)
+	}
+	r1, err := f1.Wait()
+	if err != nil || len(r1.GetPathing()) != 1 {
+		t.Fatalf("f1 = %v, %v", r1, err)
+	}
+}
+
+func TestSplitLargeQuery(t *testing.T) {
+	defer func(size int) { client.MaxMessageSize = size }(client.MaxMessageSize)
+	client.MaxMessageSize = 4 * 1024
+
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+
+	query := api.RequestQuery{}
+	for i := 0; i < 1000; i++ {
+		query.Abilities = append(query.Abilities, &api.RequestQueryAvailableAbilities{UnitTag: api.UnitTag(i)})
+	}
+	before := len(s.Requests())
+
+	r, err := c.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.GetAbilities()) != len(query.Abilities) {
+		t.Fatalf("got %v results, want %v", len(r.GetAbilities()), len(query.Abilities))
+	}
+	for i, a := range r.GetAbilities() {
+		if a.GetUnitTag() != api.UnitTag(i) {
+			t.Fatalf("result %v is for unit %v", i, a.GetUnitTag())
+		}
+	}
+	if n := len(s.Requests()) - before; n < 2 {
+		t.Errorf("query sent in %v requests, expected it to be split", n)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := &sc2test.Server{EndLoop: 10}
+	s.Start()
+	defer s.Close()
+
+	c := &client.Client{}
+	m := &client.Metrics{}
+	c.SetMetrics(m)
+	if err := c.TryConnect(s.Address(), s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	sc2test.StartGame(t, c)
+
+	if _, err := c.SendActions([]*api.Action{{}, {}}); err != nil {
+		t.Fatal(err)
+	}
+	for c.IsInGame() {
+		if err := c.Step(1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Query(api.RequestQuery{}) // fails after the game ends
+
+	requests := m.Requests()
+	if s := requests["Step"]; s.Count != 10 || s.Latency.Count != 10 || s.BytesOut == 0 || s.BytesIn == 0 {
+		t.Errorf("Step stats = %+v", s)
+	}
+	if s := requests["Query"]; s.Count != 1 || s.Errors != 1 {
+		t.Errorf("Query stats = %+v", s)
+	}
+	if n := m.Phases()["step"].Count; n != 10 {
+		t.Errorf("step phase count = %v, want 10", n)
+	}
+	if n := m.ActionResults()[api.ActionResult_Success]; n != 2 {
+		t.Errorf("successful actions = %v, want 2", n)
+	}
+}
+
+func TestLoggerAttrs(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	var buf bytes.Buffer
+	c := &client.Client{}
+	c.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	if err := c.TryConnect(s.Address(), s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	sc2test.StartGame(t, c)
+	if err := c.Step(5); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	c.Logger().Info("test")
+
+	var record struct {
+		PlayerID int `json:"player_id"`
+		GameLoop int `json:"game_loop"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.PlayerID != 1 || record.GameLoop != 5 {
+		t.Errorf("record = %+v", record)
+	}
+}
+
+func TestQuickSaveLoad(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+
+	loop := func() uint32 { return c.Observation().GetObservation().GetGameLoop() }
+	c.Step(10)
+	if err := c.QuickSave(); err != nil {
+		t.Fatal(err)
+	}
+	c.Step(10)
+	if err := c.QuickLoad(); err != nil {
+		t.Fatal(err)
+	}
+	if loop() != 10 {
+		t.Errorf("GameLoop after QuickLoad = %v, want 10", loop())
+	}
+	if err := c.RestartGame(); err != nil {
+		t.Fatal(err)
+	}
+	if loop() != 0 {
+		t.Errorf("GameLoop after RestartGame = %v, want 0", loop())
+	}
+	if err := c.Step(1); err != nil || loop() != 1 {
+		t.Errorf("Step after RestartGame = %v, %v", loop(), err)
+	}
+}
+
+func TestStepBudget(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	loop := func() uint32 { return c.Observation().GetObservation().GetGameLoop() }
+
+	c.SetStepBudget(client.StepBudget{PerStep: 10 * time.Millisecond, Policy: client.BudgetSkip})
+	c.Step(1)
+	time.Sleep(100 * time.Millisecond)
+	if err := c.Step(1); err != nil {
+		t.Fatal(err)
+	}
+	if loop() < 3 {
+		t.Errorf("GameLoop = %v, expected skipped steps", loop())
+	}
+
+	c.SetStepBudget(client.StepBudget{PerStep: 10 * time.Millisecond, TimeBank: time.Second, Policy: client.BudgetLeave})
+	time.Sleep(50 * time.Millisecond)
+	if err := c.Step(1); err != nil {
+		t.Fatalf("over budget with time left in bank: %v", err)
+	}
+
+	m := &client.Metrics{}
+	c.SetMetrics(m)
+	c.SetStepBudget(client.StepBudget{PerStep: 10 * time.Millisecond, Policy: client.BudgetLeave})
+	time.Sleep(50 * time.Millisecond)
+	var budgetErr *client.BudgetError
+	if err := c.Step(1); !errors.As(err, &budgetErr) {
+		t.Fatalf("err = %v, want BudgetError", err)
+	}
+	if c.IsInGame() {
+		t.Errorf("still in game after exceeding budget")
+	}
+	if step, ok := m.Phases()["step"]; ok {
+		t.Errorf("step phase = %+v, want none since no step was sent", step)
+	}
+}
+
+func TestSimulatedRealtime(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	c.SetSimulatedRealtime(true)
+
+	c.Step(1)
+	time.Sleep(10 * client.LoopDuration)
+	if err := c.Step(1); err != nil {
+		t.Fatal(err)
+	}
+	if loop := c.Observation().GetObservation().GetGameLoop(); loop < 11 {
+		t.Errorf("GameLoop = %v, want at least 11", loop)
+	}
+}
+
+func TestWakeAt(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	c.SetStepPolicy(client.FixedStep(16))
+
+	start := c.Observation().GetObservation().GetGameLoop()
+	c.WakeAt(start + 20)
+	c.WakeAt(start + 10)
+
+	for _, want := range []uint32{10, 20, 36} {
+		if err := c.AutoStep(); err != nil {
+			t.Fatal(err)
+		}
+		if loop := c.Observation().GetObservation().GetGameLoop(); loop != start+want {
+			t.Errorf("GameLoop = %v, want %v", loop, start+want)
+		}
+	}
+}
+
+func TestStepCheck(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	loop := func() uint32 { return c.Observation().GetObservation().GetGameLoop() }
+
+	errLimit := errors.New("limit")
+	limit := loop() + 16
+	c.SetStepCheck(func() error {
+		if loop() >= limit {
+			return errLimit
+		}
+		return nil
+	})
+
+	if err := c.Step(16); err != nil {
+		t.Fatal(err)
+	}
+	steps := map[string]func() error{
+		"Step":      func() error { return c.Step(1) },
+		"AutoStep":  c.AutoStep,
+		"StepAsync": func() error { return c.StepAsync(1).Wait() },
+		"StepAsyncContext": func() error {
+			return c.StepAsyncContext(context.Background(), 1).Wait()
+		},
+	}
+	for name, step := range steps {
+		if err := step(); err != errLimit {
+			t.Errorf("%v: err = %v, want the check's error", name, err)
+		}
+	}
+	if loop() != limit || !c.IsInGame() {
+		t.Errorf("GameLoop = %v, want %v and still in game", loop(), limit)
+	}
+
+	c.SetStepCheck(nil)
+	if err := c.Step(1); err != nil {
+		t.Errorf("Step after removing the check: %v", err)
+	}
+}
+
+func TestTapeRecordAndPlayback(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	var tape bytes.Buffer
+	c := &client.Client{}
+	c.RecordTape(&tape)
+	if err := c.TryConnect(s.Address(), s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	sc2test.StartGame(t, c)
+	for i := 0; i < 3; i++ {
+		if err := c.Step(2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := c.Observation().GetObservation().GetGameLoop()
+	c.RecordTape(nil)
+
+	// The setup exchanges are at the start of the tape
+	var types []string
+	r := client.NewTapeReader(bytes.NewReader(tape.Bytes()))
+	for {
+		entry, err := r.Next()
+		if err != nil {
+			break
+		}
+		if entry.Response == nil || entry.ElapsedTime() < 0 {
+			t.Fatalf("bad entry %v", entry)
+		}
+		types = append(types, fmt.Sprintf("%T", entry.Request.GetRequest()))
+	}
+	if len(types) < 4 || types[1] != "*api.Request_CreateGame" || types[2] != "*api.Request_JoinGame" {
+		t.Fatalf("tape = %v", types)
+	}
+
+	// Playback skips the setup and answers the same requests in order
+	p := &client.Client{}
+	if err := p.ConnectTape(bytes.NewReader(tape.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if p.PlayerID() != c.PlayerID() || !p.IsInGame() {
+		t.Fatalf("player = %v, in game = %v", p.PlayerID(), p.IsInGame())
+	}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := p.Step(2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := p.Observation().GetObservation().GetGameLoop(); got != want {
+		t.Errorf("game loop = %v, want %v", got, want)
+	}
+
+	// Anything after the end of the tape fails
+	if err := p.Step(2); err == nil {
+		t.Error("expected an error stepping past the end of the tape")
+	}
+}
+
+func TestTapeMismatch(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	var tape bytes.Buffer
+	c := &client.Client{}
+	c.RecordTape(&tape)
+	if err := c.TryConnect(s.Address(), s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	sc2test.StartGame(t, c)
+	if err := c.Step(1); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &client.Client{}
+	if err := p.ConnectTape(&tape); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Query(api.RequestQuery{}); err == nil || !strings.Contains(err.Error(), "tape mismatch") {
+		t.Errorf("err = %v, want tape mismatch", err)
+	}
+}
+
+func TestSimulatedRealtimeWithBudgetSkip(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+	c.SetSimulatedRealtime(true)
+	c.SetStepBudget(client.StepBudget{PerStep: client.LoopDuration, Policy: client.BudgetSkip})
+
+	c.Step(1)
+	time.Sleep(20 * client.LoopDuration)
+	if err := c.Step(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// About 20 loops passed in realtime, charging them twice would be about 40
+	if loop := c.Observation().GetObservation().GetGameLoop(); loop < 21 || loop > 32 {
+		t.Errorf("GameLoop = %v, want about 21", loop)
+	}
+}
@@ -1,16 +1,13 @@
 package client
 
 import (
+	"context"
 	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"reflect"
-	"sync/atomic"
-	"time"
+	"strings"
 
 	"github.com/chippydip/go-sc2ai/api"
-	"github.com/gogo/protobuf/proto"
-	"github.com/gorilla/websocket"
 )
 
 type connection struct {
@@ -18,25 +15,23 @@ type connection struct {
 
 	Status api.Status
 
-	counter  uint32
-	requests chan<- request
-}
+	// Dialer is used to connect to the game, DialWebsocket is used if it is nil.
+	Dialer Dialer
 
-type request struct {
-	data     []byte
-	response chan<- response
-}
+	pipe     *pipeline
+	recorder *tapeWriter
+	metrics  MetricsSink
 
-type response struct {
-	data []byte
-	error
+	log  *slog.Logger
+	game gameState
 }
 
 // MaxMessageSize is the largest protobuf message that can be sent without getting disconnected.
 // The gorilla/websocket implementation fragments messages above it's write buffer size and the
-// SC2 game doesn't seem to be able to deal with these messages. There is not a check in place
-// to prevent large messages from being sent and warnings will be printed if a message size
-// exceeds half of this limit. The default is now 2MB (up from 4kb) but can be overrided by
+// SC2 game doesn't seem to be able to deal with these messages. Query, Action and Debug
+// requests above this size are automatically split into several smaller requests, any other
+// request that is too large fails and warnings will be printed if a message size exceeds
+// half of this limit. The default is now 2MB (up from 4kb) but can be overrided by
 // modifying this value before connecting to SC2.
 var MaxMessageSize = 2 * 1024 * 1024
 
@@ -44,31 +39,23 @@ var MaxMessageSize = 2 * 1024 * 1024
 func (c *connection) Connect(address string, port int) error {
 	c.Status = api.Status_unknown
 
-	dialer := websocket.Dialer{WriteBufferSize: MaxMessageSize}
-	url := fmt.Sprintf("ws://%v:%v/sc2api", address, port)
+	dial := c.Dialer
+	if dial == nil {
+		dial = DialWebsocket
+	}
 
-	ws, _, err := dialer.Dial(url, nil)
+	t, err := dial(address, port)
 	if err != nil {
 		return err
 	}
+	return c.attach(t)
+}
 
-	requests := make(chan request)
-	ws.SetCloseHandler(func(code int, text string) error {
-		close(requests)
-		return nil
-	})
-	c.requests = requests
-
-	// Worker
-	go func() {
-		defer recoverPanic()
-
-		for r := range requests {
-			r.process(ws)
-		}
-	}()
+// attach starts using the transport for requests and pings the game to get version info.
+func (c *connection) attach(t Transport) error {
+	c.pipe = newPipeline(t, c.recorder, c.metrics, c.logger())
 
-	r, err := c.ping(api.RequestPing{})
+	r, err := c.ping(context.Background(), api.RequestPing{})
 	if err != nil || r == nil {
 		return err
 	}
@@ -76,77 +63,59 @@ func (c *connection) Connect(address string, port int) error {
 	return nil
 }
 
-func (r request) process(ws *websocket.Conn) {
-	data, err := []byte(nil), ws.WriteMessage(websocket.BinaryMessage, r.data)
-	if err == nil {
-		_, data, err = ws.ReadMessage()
+// send starts a request without waiting for the response.
+func (c *connection) send(ctx context.Context, r *api.Request) *call {
+	name := strings.TrimPrefix(reflect.TypeOf(r.Request).String(), "*api.Request_")
+	if c.pipe == nil {
+		return finishedCall(ctx, name, &ConnectionClosedError{errors.New("not connected")})
 	}
-
-	r.response <- response{data, err}
-	close(r.response)
+	if parts := splitRequest(r, c.logger()); parts != nil {
+		return c.sendParts(ctx, name, parts)
+	}
+	return c.pipe.send(ctx, r, name)
 }
 
-func (c *connection) sendRecv(data []byte, name string) ([]byte, error) {
-	out := make(chan response)
-	c.requests <- request{data, out}
+// sendParts sends each part of a split request and returns a call that completes with the
+// merged response once all of them have been received.
+func (c *connection) sendParts(ctx context.Context, name string, parts []*api.Request) *call {
+	calls := make([]*call, len(parts))
+	for i, part := range parts {
+		calls[i] = c.pipe.send(ctx, part, name)
+	}
 
-	for {
-		select {
-		case r := <-out:
-			return r.data, r.error
-		case <-time.After(10 * time.Second):
-			log.Printf("waiting for %v response", name)
+	cl := &call{ctx: ctx, name: name, log: c.logger(), done: make(chan struct{})}
+	go func() {
+		resps := make([]*api.Response, len(calls))
+		for i, part := range calls {
+			<-part.done
+			if part.err != nil {
+				cl.finish(part.resp, part.err)
+				return
+			}
+			resps[i] = part.resp
 		}
-	}
+		cl.finish(mergeResponses(resps), nil)
+	}()
+	return cl
 }
 
-func (c *connection) request(r *api.Request) (*api.Response, error) {
-	r.Id = atomic.AddUint32(&c.counter, 1)
-
-	// Serialize
-	data, err := proto.Marshal(r)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(data) > MaxMessageSize {
-		err = fmt.Errorf("message too large: %v (max %v)", len(data), MaxMessageSize)
-		log.Print(err)
-		return nil, err
-	} else if len(data) > MaxMessageSize/2 {
-		log.Print("warning, large message size: ", len(data))
-	}
+// wait blocks until the call completes and updates the connection status from the response.
+func (c *connection) wait(cl *call) (*api.Response, error) {
+	resp, err := cl.wait()
 
-	// Send/Recv
-	data, err = c.sendRecv(data, reflect.TypeOf(r.Request).String())
-	if err != nil {
-		return nil, err
-	}
+	// Update status
+	cl.statusOnce.Do(func() {
+		if resp.GetStatus() != api.Status_nil {
+			c.Status = resp.Status
+		}
+	})
 
-	// Deserialize
-	resp := &api.Response{}
-	err = proto.Unmarshal(data, resp)
 	if err != nil {
 		return nil, err
 	}
+	return resp, nil
+}
 
-	// Update status
-	if resp.Status != api.Status_nil {
-		c.Status = resp.Status
-	}
-
-	// Check Id
-	if resp.Id != 0 && resp.Id != r.Id {
-		log.Printf("bad response ID: got %v, expected %v", resp.Id, r.Id)
-	}
-
-	// Report errors (if any) and return
-	switch len(resp.Error) {
-	case 0:
-		return resp, nil
-	case 1:
-		return nil, errors.New(resp.Error[0])
-	default:
-		return nil, fmt.Errorf("%v", resp.Error)
-	}
+func (c *connection) request(ctx context.Context, r *api.Request) (*api.Response, error) {
+	return c.wait(c.send(ctx, r))
 }
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chippydip/go-sc2ai/api"
+)
+
+// ProtocolError is returned when the game reports one or more errors in Response.Error.
+type ProtocolError struct {
+	Request string
+	Errors  []string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Request, strings.Join(e.Errors, "; "))
+}
+
+// GameError is returned when the game rejects a request to create, join or restart a game, to
+// start or inspect a replay, to save a map, or to run a map command. Only the code for the
+// request that failed will be set.
+type GameError struct {
+	CreateGame  api.ResponseCreateGame_Error
+	JoinGame    api.ResponseJoinGame_Error
+	RestartGame api.ResponseRestartGame_Error
+	StartReplay api.ResponseStartReplay_Error
+	ReplayInfo  api.ResponseReplayInfo_Error
+	MapCommand  api.ResponseMapCommand_Error
+	SaveMap     api.ResponseSaveMap_Error
+	Details     string
+}
+
+func (e *GameError) Error() string {
+	var code fmt.Stringer
+	switch {
+	case e.CreateGame != api.ResponseCreateGame_nil:
+		code = e.CreateGame
+	case e.JoinGame != api.ResponseJoinGame_nil:
+		code = e.JoinGame
+	case e.RestartGame != api.ResponseRestartGame_nil:
+		code = e.RestartGame
+	case e.StartReplay != api.ResponseStartReplay_nil:
+		code = e.StartReplay
+	case e.ReplayInfo != api.ResponseReplayInfo_nil:
+		code = e.ReplayInfo
+	case e.MapCommand != api.ResponseMapCommand_nil:
+		code = e.MapCommand
+	case e.SaveMap != api.ResponseSaveMap_nil:
+		code = e.SaveMap
+	default:
+		return e.Details
+	}
+	return fmt.Sprintf("%v: %v", code, e.Details)
+}
+
+// ConnectionClosedError is returned when a request can't be completed because the connection
+// to the game was never established, has been closed, or failed in the middle of a request.
+type ConnectionClosedError struct {
+	Err error
+}
+
+func (e *ConnectionClosedError) Error() string {
+	if e.Err == nil {
+		return "connection closed"
+	}
+	return fmt.Sprintf("connection closed: %v", e.Err)
+}
+
+// Unwrap returns the underlying cause (if known).
+func (e *ConnectionClosedError) Unwrap() error {
+	return e.Err
+}
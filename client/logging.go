@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/chippydip/go-sc2ai/api"
+)
+
+// SetLogger sets the logger used for everything the client (and any bot code using it) logs.
+// Records are tagged with the player ID and current game loop once the client has joined a
+// game. The default is slog.Default().
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.connection.setLogger(logger)
+}
+
+// Logger returns the client's logger.
+func (c *Client) Logger() *slog.Logger {
+	return c.connection.logger()
+}
+
+// ReportPanic logs a recovered panic value along with the stack trace to the client's logger.
+func (c *Client) ReportPanic(p interface{}) {
+	reportPanic(c.Logger(), p)
+}
+
+// gameState holds the values added to log records. It is updated by the client and read
+// from any goroutine that logs.
+type gameState struct {
+	playerID atomic.Uint32
+	gameLoop atomic.Uint32
+	inGame   atomic.Bool
+}
+
+// setObservation updates the game loop from a new observation.
+func (g *gameState) setObservation(obs *api.ResponseObservation) {
+	if obs != nil {
+		g.gameLoop.Store(obs.GetObservation().GetGameLoop())
+		g.inGame.Store(true)
+	}
+}
+
+func (c *connection) setLogger(logger *slog.Logger) {
+	c.log = nil
+	if logger != nil {
+		c.log = slog.New(&gameHandler{logger.Handler(), &c.game})
+	}
+	if c.pipe != nil {
+		c.pipe.setLogger(c.logger())
+	}
+}
+
+func (c *connection) logger() *slog.Logger {
+	if c.log == nil {
+		return slog.New(&gameHandler{slog.Default().Handler(), &c.game})
+	}
+	return c.log
+}
+
+// gameHandler adds the player ID and game loop to every record.
+type gameHandler struct {
+	slog.Handler
+	game *gameState
+}
+
+func (h *gameHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := h.game.playerID.Load(); id != 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Int("player_id", int(id)))
+	}
+	if h.game.inGame.Load() {
+		r = r.Clone()
+		r.AddAttrs(slog.Int("game_loop", int(h.game.gameLoop.Load())))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *gameHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &gameHandler{h.Handler.WithAttrs(attrs), h.game}
+}
+
+func (h *gameHandler) WithGroup(name string) slog.Handler {
+	return &gameHandler{h.Handler.WithGroup(name), h.game}
+}
@@ -0,0 +1,314 @@
+package client
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+)
+
+// MetricsSink receives measurements from a Client. Methods may be called concurrently.
+type MetricsSink interface {
+	// ObserveRequest is called when a request completes. The name is the request type (for
+	// example "Step" or "Query"), latency is the time from writing the request until the
+	// response was received, and the sizes are the serialized message lengths. A latency of
+	// zero and bytesIn of zero means no response was received.
+	ObserveRequest(name string, latency time.Duration, bytesOut, bytesIn int, err error)
+
+	// ObserveActions is called with the results of every batch of actions sent to the game.
+	ObserveActions(results []api.ActionResult)
+
+	// ObservePhase is called once per step with the time spent in each phase of the step
+	// ("beforeStep", "step", "observation" and "afterStep") and with the time the agent
+	// spent running its own code between steps ("agent").
+	ObservePhase(phase string, d time.Duration)
+}
+
+// SetMetrics starts reporting measurements to sink, or stops if it is nil.
+func (c *Client) SetMetrics(sink MetricsSink) {
+	c.connection.metrics = sink
+	if c.connection.pipe != nil {
+		c.connection.pipe.setMetrics(sink)
+	}
+}
+
+func (c *Client) observePhase(phase string, d time.Duration) {
+	if c.connection.metrics != nil {
+		c.connection.metrics.ObservePhase(phase, d)
+	}
+}
+
+// LatencyBuckets are the upper bounds of the histogram buckets used by Metrics. Durations
+// above the last bound are counted in an extra overflow bucket.
+var LatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	1 * time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Histogram counts durations in LatencyBuckets.
+type Histogram struct {
+	Buckets []int64       `json:"buckets"`
+	Count   int64         `json:"count"`
+	Sum     time.Duration `json:"sum"`
+	Max     time.Duration `json:"max"`
+}
+
+func (h *Histogram) add(d time.Duration) {
+	if h.Buckets == nil {
+		h.Buckets = make([]int64, len(LatencyBuckets)+1)
+	}
+	i := sort.Search(len(LatencyBuckets), func(i int) bool { return d <= LatencyBuckets[i] })
+	h.Buckets[i]++
+	h.Count++
+	h.Sum += d
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+// Mean returns the average duration.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns an estimate of the q-th quantile (0-1) using the bucket upper bounds.
+func (h Histogram) Quantile(q float64) time.Duration {
+	target := int64(q*float64(h.Count) + 0.5)
+	if target < 1 {
+		target = 1
+	}
+	var n int64
+	for i, count := range h.Buckets {
+		if n += count; n >= target {
+			if i < len(LatencyBuckets) && LatencyBuckets[i] < h.Max {
+				return LatencyBuckets[i]
+			}
+			return h.Max
+		}
+	}
+	return h.Max
+}
+
+func (h Histogram) clone() Histogram {
+	h.Buckets = append([]int64(nil), h.Buckets...)
+	return h
+}
+
+// RequestStats are the measurements for a single request type.
+type RequestStats struct {
+	Count    int64     `json:"count"`
+	Errors   int64     `json:"errors"`
+	BytesOut int64     `json:"bytes_out"`
+	BytesIn  int64     `json:"bytes_in"`
+	Latency  Histogram `json:"latency"`
+}
+
+// Metrics is a MetricsSink that keeps running totals in memory. The zero value is ready to
+// use. It implements expvar.Var so it can be exposed with expvar.Publish.
+type Metrics struct {
+	mu       sync.Mutex
+	requests map[string]*RequestStats
+	phases   map[string]*Histogram
+	actions  map[api.ActionResult]int64
+}
+
+var _ MetricsSink = (*Metrics)(nil)
+var _ expvar.Var = (*Metrics)(nil)
+
+// ObserveRequest implements MetricsSink.
+func (m *Metrics) ObserveRequest(name string, latency time.Duration, bytesOut, bytesIn int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.requests == nil {
+		m.requests = map[string]*RequestStats{}
+	}
+	s := m.requests[name]
+	if s == nil {
+		s = &RequestStats{}
+		m.requests[name] = s
+	}
+
+	s.Count++
+	if err != nil {
+		s.Errors++
+	}
+	s.BytesOut += int64(bytesOut)
+	s.BytesIn += int64(bytesIn)
+	if bytesIn > 0 {
+		s.Latency.add(latency)
+	}
+}
+
+// ObserveActions implements MetricsSink.
+func (m *Metrics) ObserveActions(results []api.ActionResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.actions == nil {
+		m.actions = map[api.ActionResult]int64{}
+	}
+	for _, r := range results {
+		m.actions[r]++
+	}
+}
+
+// ObservePhase implements MetricsSink.
+func (m *Metrics) ObservePhase(phase string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.phases == nil {
+		m.phases = map[string]*Histogram{}
+	}
+	h := m.phases[phase]
+	if h == nil {
+		h = &Histogram{}
+		m.phases[phase] = h
+	}
+	h.add(d)
+}
+
+// Requests returns a copy of the stats for each request type.
+func (m *Metrics) Requests() map[string]RequestStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests := make(map[string]RequestStats, len(m.requests))
+	for name, s := range m.requests {
+		c := *s
+		c.Latency = s.Latency.clone()
+		requests[name] = c
+	}
+	return requests
+}
+
+// Phases returns a copy of the step phase timings.
+func (m *Metrics) Phases() map[string]Histogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	phases := make(map[string]Histogram, len(m.phases))
+	for name, h := range m.phases {
+		phases[name] = h.clone()
+	}
+	return phases
+}
+
+// ActionResults returns the number of times each action result was received.
+func (m *Metrics) ActionResults() map[api.ActionResult]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	actions := make(map[api.ActionResult]int64, len(m.actions))
+	for r, n := range m.actions {
+		actions[r] = n
+	}
+	return actions
+}
+
+// AcceptRatio returns the fraction of actions that the game accepted (or 1 if none were sent).
+func (m *Metrics) AcceptRatio() float64 {
+	var total, accepted int64
+	for r, n := range m.ActionResults() {
+		total += n
+		if r == api.ActionResult_Success {
+			accepted += n
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(accepted) / float64(total)
+}
+
+// String returns the metrics as JSON (for expvar).
+func (m *Metrics) String() string {
+	actions := map[string]int64{}
+	for r, n := range m.ActionResults() {
+		actions[r.String()] = n
+	}
+
+	data, err := json.Marshal(struct {
+		Requests    map[string]RequestStats `json:"requests"`
+		Phases      map[string]Histogram    `json:"phases"`
+		Actions     map[string]int64        `json:"actions"`
+		AcceptRatio float64                 `json:"accept_ratio"`
+	}{m.Requests(), m.Phases(), actions, m.AcceptRatio()})
+	if err != nil {
+		return fmt.Sprintf("%q", err.Error())
+	}
+	return string(data)
+}
+
+// Summary returns a human readable report suitable for logging at the end of a game.
+func (m *Metrics) Summary() string {
+	var sb strings.Builder
+
+	requests := m.Requests()
+	var names []string
+	for name := range requests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(&sb, "%-16v %8v %6v %10v %10v %10v %10v %10v %10v\n",
+		"request", "count", "errors", "mean", "p50", "p95", "max", "bytesOut", "bytesIn")
+	for _, name := range names {
+		s := requests[name]
+		h := s.Latency
+		fmt.Fprintf(&sb, "%-16v %8v %6v %10v %10v %10v %10v %10v %10v\n",
+			name, s.Count, s.Errors, h.Mean(), h.Quantile(0.5), h.Quantile(0.95), h.Max, s.BytesOut, s.BytesIn)
+	}
+
+	phases := m.Phases()
+	if len(phases) > 0 {
+		fmt.Fprintf(&sb, "\n%-16v %8v %10v %10v %10v %10v\n", "phase", "count", "mean", "p50", "p95", "max")
+		for _, name := range []string{"agent", "beforeStep", "step", "observation", "afterStep"} {
+			if h, ok := phases[name]; ok {
+				fmt.Fprintf(&sb, "%-16v %8v %10v %10v %10v %10v\n",
+					name, h.Count, h.Mean(), h.Quantile(0.5), h.Quantile(0.95), h.Max)
+			}
+		}
+	}
+
+	actions := m.ActionResults()
+	if len(actions) > 0 {
+		var results []api.ActionResult
+		for r := range actions {
+			if r != api.ActionResult_Success {
+				results = append(results, r)
+			}
+		}
+		sort.Slice(results, func(i, j int) bool { return results[i] < results[j] })
+
+		fmt.Fprintf(&sb, "\nactions accepted: %.1f%%\n", 100*m.AcceptRatio())
+		for _, r := range results {
+			fmt.Fprintf(&sb, "  %v: %v\n", r, actions[r])
+		}
+	}
+
+	return strings.Replace(sb.String(), "µ", "u", -1)
+}
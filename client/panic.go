@@ -1,7 +1,8 @@
 package client
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"runtime"
 )
 
@@ -11,19 +12,24 @@ func recoverPanic() {
 	}
 }
 
-// ReportPanic ...
+// ReportPanic logs a recovered panic value along with the stack trace to slog.Default().
 func ReportPanic(p interface{}) {
-	log.Print(p)
+	reportPanic(slog.Default(), p)
+}
 
+func reportPanic(logger *slog.Logger, p interface{}) {
 	// Nicer format than what debug.PrintStack() gives us
 	var pc [32]uintptr
-	n := runtime.Callers(3, pc[:]) // skip the defer, this func, and runtime.Callers
+	n := runtime.Callers(4, pc[:]) // skip the defer, the exported func, this func, and runtime.Callers
+	var stack []string
 	for _, pc := range pc[:n] {
 		fn := runtime.FuncForPC(pc)
 		if fn == nil {
 			continue
 		}
 		file, line := fn.FileLine(pc)
-		log.Printf("%v:%v in %v", file, line, fn.Name())
+		stack = append(stack, fmt.Sprintf("%v:%v in %v", file, line, fn.Name()))
 	}
+
+	logger.Error(fmt.Sprint(p), "stack", stack)
 }
@@ -0,0 +1,269 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/gogo/protobuf/proto"
+)
+
+// pipeline allows multiple requests to be in flight on a single socket at once. Requests are
+// written in the order they are sent and the game answers them in the same order, so each
+// response is matched against the oldest pending call and must carry the same request ID.
+type pipeline struct {
+	ws Transport
+
+	writeMu  sync.Mutex
+	counter  uint32
+	recorder *tapeWriter
+	metrics  MetricsSink
+	log      *slog.Logger
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*call
+	err     error
+}
+
+// call is a single request/response exchange. It is completed exactly once.
+type call struct {
+	ctx  context.Context
+	id   uint32
+	name string
+	log  *slog.Logger
+	done chan struct{}
+	once sync.Once
+
+	resp *api.Response
+	err  error
+
+	recorder *tapeWriter
+	data     []byte
+	sent     time.Time
+
+	metrics  MetricsSink
+	bytesOut int
+	bytesIn  int
+	latency  time.Duration
+
+	statusOnce sync.Once
+}
+
+func newPipeline(ws Transport, recorder *tapeWriter, metrics MetricsSink, log *slog.Logger) *pipeline {
+	p := &pipeline{ws: ws, recorder: recorder, metrics: metrics, log: log}
+	p.cond = sync.NewCond(&p.mu)
+
+	go func() {
+		defer recoverPanic()
+		p.readLoop()
+	}()
+	return p
+}
+
+// send writes the request immediately and returns a call that will be completed when the
+// matching response is received (or the pipeline fails).
+func (p *pipeline) send(ctx context.Context, r *api.Request, name string) *call {
+	cl := &call{ctx: ctx, name: name, done: make(chan struct{})}
+
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	cl.metrics, cl.log = p.metrics, p.log
+	if err := ctx.Err(); err != nil {
+		cl.finish(nil, err)
+		return cl
+	}
+
+	cl.id = atomic.AddUint32(&p.counter, 1)
+	r.Id = cl.id
+
+	// Serialize
+	data, err := proto.Marshal(r)
+	if err != nil {
+		cl.finish(nil, err)
+		return cl
+	}
+
+	cl.bytesOut = len(data)
+	if len(data) > MaxMessageSize {
+		err = fmt.Errorf("message too large: %v (max %v)", len(data), MaxMessageSize)
+		p.log.Error(err.Error(), "request", name)
+		cl.finish(nil, err)
+		return cl
+	} else if len(data) > MaxMessageSize/2 {
+		p.log.Warn("large message size", "request", name, "size", len(data))
+	}
+
+	cl.sent = time.Now()
+	if p.recorder != nil {
+		cl.recorder, cl.data = p.recorder, data
+	}
+
+	// Queue before writing so the reader can never see a response it doesn't know about
+	p.mu.Lock()
+	if p.err != nil {
+		p.mu.Unlock()
+		cl.finish(nil, &ConnectionClosedError{p.err})
+		return cl
+	}
+	p.pending = append(p.pending, cl)
+	p.cond.Signal()
+	p.mu.Unlock()
+
+	// Closing the socket is the only way to interrupt a blocked read or write. The game will
+	// still try to send a response so there is no way to recover the connection afterwards.
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				p.fail(ctx.Err())
+			case <-cl.done:
+			}
+		}()
+	}
+
+	if err := p.ws.WriteMessage(data); err != nil {
+		p.fail(err)
+	}
+	return cl
+}
+
+// readLoop matches responses to pending calls until the socket fails.
+func (p *pipeline) readLoop() {
+	for {
+		p.mu.Lock()
+		for len(p.pending) == 0 && p.err == nil {
+			p.cond.Wait()
+		}
+		if p.err != nil {
+			p.mu.Unlock()
+			return
+		}
+		cl := p.pending[0]
+		p.mu.Unlock()
+
+		data, err := p.ws.ReadMessage()
+		if err != nil {
+			p.fail(err)
+			return
+		}
+
+		// Deserialize
+		resp := &api.Response{}
+		if err := proto.Unmarshal(data, resp); err != nil {
+			p.fail(err)
+			return
+		}
+
+		// Check Id, a mismatch means we can no longer tell which response belongs to which request
+		if resp.Id != 0 && resp.Id != cl.id {
+			p.fail(fmt.Errorf("bad response ID: got %v, expected %v", resp.Id, cl.id))
+			return
+		}
+
+		p.mu.Lock()
+		if p.err != nil {
+			p.mu.Unlock()
+			return // already failed and completed
+		}
+		p.pending = p.pending[1:]
+		p.mu.Unlock()
+
+		latency := time.Since(cl.sent)
+		if cl.recorder != nil {
+			cl.recorder.record(cl.data, data, cl.sent, latency)
+		}
+
+		// Report errors (if any)
+		if len(resp.Error) > 0 {
+			cl.complete(resp, &ProtocolError{cl.name, resp.Error}, latency, len(data))
+		} else {
+			cl.complete(resp, nil, latency, len(data))
+		}
+	}
+}
+
+// fail closes the socket and completes all pending calls with an error.
+func (p *pipeline) fail(err error) {
+	p.mu.Lock()
+	if p.err != nil {
+		p.mu.Unlock()
+		return
+	}
+	p.err = err
+	pending := p.pending
+	p.pending = nil
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	p.ws.Close()
+
+	for _, cl := range pending {
+		if cl.ctx.Err() != nil {
+			cl.finish(nil, cl.ctx.Err()) // report the cancellation rather than the resulting socket error
+		} else {
+			cl.finish(nil, &ConnectionClosedError{err})
+		}
+	}
+}
+
+// setMetrics changes the metrics sink used for subsequent requests.
+func (p *pipeline) setMetrics(metrics MetricsSink) {
+	p.writeMu.Lock()
+	p.metrics = metrics
+	p.writeMu.Unlock()
+}
+
+// setLogger changes the logger used for subsequent requests.
+func (p *pipeline) setLogger(log *slog.Logger) {
+	p.writeMu.Lock()
+	p.log = log
+	p.writeMu.Unlock()
+}
+
+// setRecorder changes the tape writer used for subsequent requests.
+func (p *pipeline) setRecorder(recorder *tapeWriter) {
+	p.writeMu.Lock()
+	p.recorder = recorder
+	p.writeMu.Unlock()
+}
+
+// finishedCall returns a call that has already failed with err.
+func finishedCall(ctx context.Context, name string, err error) *call {
+	cl := &call{ctx: ctx, name: name, done: make(chan struct{})}
+	cl.finish(nil, err)
+	return cl
+}
+
+func (cl *call) finish(resp *api.Response, err error) {
+	cl.complete(resp, err, 0, 0)
+}
+
+// complete finishes the call with the response and how long it took to arrive.
+func (cl *call) complete(resp *api.Response, err error, latency time.Duration, bytesIn int) {
+	cl.once.Do(func() {
+		cl.resp, cl.err = resp, err
+		cl.latency, cl.bytesIn = latency, bytesIn
+		if cl.metrics != nil {
+			cl.metrics.ObserveRequest(cl.name, cl.latency, cl.bytesOut, cl.bytesIn, err)
+		}
+		close(cl.done)
+	})
+}
+
+// wait blocks until the call completes, periodically logging if the game is slow to respond.
+func (cl *call) wait() (*api.Response, error) {
+	for {
+		select {
+		case <-cl.done:
+			return cl.resp, cl.err
+		case <-time.After(10 * time.Second):
+			cl.log.Info("waiting for response", "request", cl.name)
+		}
+	}
+}
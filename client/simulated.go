@@ -0,0 +1,40 @@
+package client
+
+import "time"
+
+// SetSimulatedRealtime enables (or disables) simulated realtime mode. The game is still run in
+// step mode, but each step advances the game by at least the number of game loops that would
+// have passed in realtime while the agent was working (measured from receiving the previous
+// observation to requesting the next step). This reproduces the cost of slow agent code in a
+// realtime game while still allowing the game itself to run as fast as possible. It has no
+// effect if the game is actually running in realtime.
+func (c *Client) SetSimulatedRealtime(enabled bool) {
+	c.simulatedRealtime = enabled
+}
+
+// IsSimulatedRealtime returns true if simulated realtime mode is enabled.
+func (c *Client) IsSimulatedRealtime() bool {
+	return c.simulatedRealtime
+}
+
+// ResetStepTimer restarts the clock simulated realtime and the step budget use to measure how
+// long the agent took since the last observation, so time spent deliberately waiting (e.g.
+// while paused) isn't charged to the agent on the next step.
+func (c *Client) ResetStepTimer() {
+	if !c.observationEnd.IsZero() {
+		c.observationEnd = time.Now()
+	}
+}
+
+// simulateRealtime returns the number of game loops to step after the agent spent elapsed
+// time on the previous one.
+func (c *Client) simulateRealtime(stepSize int, elapsed time.Duration) int {
+	if !c.simulatedRealtime || c.realtime {
+		return stepSize
+	}
+	if loops := int(elapsed / LoopDuration); loops > stepSize {
+		c.simulatedLoops += loops - stepSize
+		return loops
+	}
+	return stepSize
+}
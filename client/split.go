@@ -0,0 +1,220 @@
+package client
+
+import (
+	"log/slog"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/gogo/protobuf/proto"
+)
+
+// splitRequest breaks a Query, Action or Debug request that is too large to send as a single
+// message into several smaller requests. It returns nil if r doesn't need to be split (or
+// can't be). Items are kept in their original order so the responses can simply be joined.
+func splitRequest(r *api.Request, log *slog.Logger) []*api.Request {
+	switch req := r.Request.(type) {
+	case *api.Request_Query:
+		if proto.Size(r) > MaxMessageSize {
+			return splitQuery(req.Query)
+		}
+	case *api.Request_Action:
+		if proto.Size(r) > MaxMessageSize {
+			return splitAction(req.Action)
+		}
+	case *api.Request_Debug:
+		if proto.Size(r) > MaxMessageSize {
+			return splitDebug(req.Debug, log)
+		}
+	}
+	return nil
+}
+
+// batcher tracks the encoded size of the current batch of repeated items.
+type batcher struct {
+	size int
+}
+
+// splitLimit is the number of bytes of repeated items to allow per message, leaving some
+// room for the request envelope and any non-repeated fields.
+func splitLimit() int {
+	return MaxMessageSize - 1024
+}
+
+// itemSize returns the encoded size of item as an element of a repeated field.
+func itemSize(item proto.Message) int {
+	n := proto.Size(item)
+	return 1 + proto.SizeVarint(uint64(n)) + n
+}
+
+// add reserves space for an item and reports whether it must start a new batch.
+func (b *batcher) add(item proto.Message) bool {
+	n := itemSize(item)
+	split := b.size > 0 && b.size+n > splitLimit()
+	if split {
+		b.size = 0
+	}
+	b.size += n
+	return split
+}
+
+func splitQuery(q *api.RequestQuery) []*api.Request {
+	var b batcher
+	parts := []*api.RequestQuery{{IgnoreResourceRequirements: q.IgnoreResourceRequirements}}
+	next := func(item proto.Message) *api.RequestQuery {
+		if b.add(item) {
+			parts = append(parts, &api.RequestQuery{IgnoreResourceRequirements: q.IgnoreResourceRequirements})
+		}
+		return parts[len(parts)-1]
+	}
+
+	for _, item := range q.Pathing {
+		part := next(item)
+		part.Pathing = append(part.Pathing, item)
+	}
+	for _, item := range q.Abilities {
+		part := next(item)
+		part.Abilities = append(part.Abilities, item)
+	}
+	for _, item := range q.Placements {
+		part := next(item)
+		part.Placements = append(part.Placements, item)
+	}
+
+	requests := make([]*api.Request, len(parts))
+	for i, part := range parts {
+		requests[i] = &api.Request{Request: &api.Request_Query{Query: part}}
+	}
+	return requests
+}
+
+func splitAction(a *api.RequestAction) []*api.Request {
+	var b batcher
+	parts := []*api.RequestAction{{}}
+	for _, item := range a.Actions {
+		if b.add(item) {
+			parts = append(parts, &api.RequestAction{})
+		}
+		part := parts[len(parts)-1]
+		part.Actions = append(part.Actions, item)
+	}
+
+	requests := make([]*api.Request, len(parts))
+	for i, part := range parts {
+		requests[i] = &api.Request{Request: &api.Request_Action{Action: part}}
+	}
+	return requests
+}
+
+// splitDebug splits the commands of a debug request. Each debug request that contains draw
+// commands replaces everything that was previously drawn, so all draw commands are kept
+// together in the last request. If they don't fit in a single message on their own the
+// excess primitives are dropped (with a warning) rather than losing the whole request.
+func splitDebug(d *api.RequestDebug, log *slog.Logger) []*api.Request {
+	var commands, draws []*api.DebugCommand
+	drawSize := 0
+	for _, cmd := range d.Debug {
+		if _, ok := cmd.Command.(*api.DebugCommand_Draw); ok {
+			draws = append(draws, cmd)
+			drawSize += itemSize(cmd)
+		} else {
+			commands = append(commands, cmd)
+		}
+	}
+	if drawSize > splitLimit() {
+		var dropped int
+		draws, dropped = truncateDraws(draws)
+		log.Warn("debug draw too large", "dropped", dropped)
+		drawSize = itemSize(draws[0])
+	}
+
+	var b batcher
+	parts := []*api.RequestDebug{{}}
+	for _, item := range commands {
+		if b.add(item) {
+			parts = append(parts, &api.RequestDebug{})
+		}
+		part := parts[len(parts)-1]
+		part.Debug = append(part.Debug, item)
+	}
+
+	if len(draws) > 0 {
+		if b.size > 0 && b.size+drawSize > splitLimit() {
+			parts = append(parts, &api.RequestDebug{})
+		}
+		part := parts[len(parts)-1]
+		part.Debug = append(part.Debug, draws...)
+	}
+
+	requests := make([]*api.Request, len(parts))
+	for i, part := range parts {
+		requests[i] = &api.Request{Request: &api.Request_Debug{Debug: part}}
+	}
+	return requests
+}
+
+// truncateDraws combines all draw commands into one, keeping as many primitives as will fit,
+// and returns the number that were dropped.
+func truncateDraws(draws []*api.DebugCommand) ([]*api.DebugCommand, int) {
+	draw := &api.DebugDraw{}
+	size, limit, dropped := 0, splitLimit()-16, 0
+	fits := func(item proto.Message) bool {
+		n := itemSize(item)
+		if size+n > limit {
+			dropped++
+			return false
+		}
+		size += n
+		return true
+	}
+
+	for _, cmd := range draws {
+		d := cmd.GetDraw()
+		for _, item := range d.Text {
+			if fits(item) {
+				draw.Text = append(draw.Text, item)
+			}
+		}
+		for _, item := range d.Lines {
+			if fits(item) {
+				draw.Lines = append(draw.Lines, item)
+			}
+		}
+		for _, item := range d.Boxes {
+			if fits(item) {
+				draw.Boxes = append(draw.Boxes, item)
+			}
+		}
+		for _, item := range d.Spheres {
+			if fits(item) {
+				draw.Spheres = append(draw.Spheres, item)
+			}
+		}
+	}
+
+	return []*api.DebugCommand{{Command: &api.DebugCommand_Draw{Draw: draw}}}, dropped
+}
+
+// mergeResponses joins the responses to a split request back into a single response.
+func mergeResponses(parts []*api.Response) *api.Response {
+	merged := *parts[len(parts)-1]
+	merged.Id = 0
+
+	switch merged.Response.(type) {
+	case *api.Response_Query:
+		query := &api.ResponseQuery{}
+		for _, part := range parts {
+			q := part.GetQuery()
+			query.Pathing = append(query.Pathing, q.GetPathing()...)
+			query.Abilities = append(query.Abilities, q.GetAbilities()...)
+			query.Placements = append(query.Placements, q.GetPlacements()...)
+		}
+		merged.Response = &api.Response_Query{Query: query}
+
+	case *api.Response_Action:
+		action := &api.ResponseAction{}
+		for _, part := range parts {
+			action.Result = append(action.Result, part.GetAction().GetResult()...)
+		}
+		merged.Response = &api.Response_Action{Action: action}
+	}
+	return &merged
+}
@@ -0,0 +1,72 @@
+package client
+
+import "sort"
+
+// StepPolicy chooses how many game loops AutoStep advances the game each time it is called.
+type StepPolicy interface {
+	StepSize(info AgentInfo) int
+}
+
+// StepPolicyFunc adapts an ordinary function to a StepPolicy.
+type StepPolicyFunc func(info AgentInfo) int
+
+// StepSize calls f(info).
+func (f StepPolicyFunc) StepSize(info AgentInfo) int {
+	return f(info)
+}
+
+// FixedStep is a StepPolicy that always steps the same number of game loops.
+type FixedStep int
+
+// StepSize returns s.
+func (s FixedStep) StepSize(info AgentInfo) int {
+	return int(s)
+}
+
+// SetStepPolicy sets the policy used by AutoStep, nil resets it to stepping one loop at a time.
+func (c *Client) SetStepPolicy(policy StepPolicy) {
+	c.stepPolicy = policy
+}
+
+// SetStepCheck sets a function that is called before every step (whether from Step, AutoStep
+// or StepAsync). If it returns an error the step isn't started and the error is returned
+// instead. This lets a runner stop an agent at a limit the agent doesn't know about. Nil
+// removes the check.
+func (c *Client) SetStepCheck(check func() error) {
+	c.stepCheck = check
+}
+
+// AutoStep steps the game forward by the number of loops chosen by the current StepPolicy.
+func (c *Client) AutoStep() error {
+	stepSize := 1
+	if c.stepPolicy != nil {
+		stepSize = c.stepPolicy.StepSize(c)
+	}
+	return c.Step(stepSize)
+}
+
+// WakeAt ensures that no step (whether from Step or AutoStep) skips past the given game loop,
+// so the agent is guaranteed to get an observation exactly at that point. Simulated realtime
+// and the BudgetSkip policy may still step past it. In realtime mode the next observation is
+// requested for that game loop, but the game may already be past it if the agent was slow.
+func (c *Client) WakeAt(gameLoop uint32) {
+	i := sort.Search(len(c.wakeLoops), func(i int) bool { return c.wakeLoops[i] >= gameLoop })
+	if i < len(c.wakeLoops) && c.wakeLoops[i] == gameLoop {
+		return
+	}
+	c.wakeLoops = append(c.wakeLoops, 0)
+	copy(c.wakeLoops[i+1:], c.wakeLoops[i:])
+	c.wakeLoops[i] = gameLoop
+}
+
+// clampStep limits stepSize so the game doesn't advance past the next requested wake loop.
+func (c *Client) clampStep(stepSize int) int {
+	gameLoop := c.observation.GetObservation().GetGameLoop()
+	for len(c.wakeLoops) > 0 && c.wakeLoops[0] <= gameLoop {
+		c.wakeLoops = c.wakeLoops[1:]
+	}
+	if len(c.wakeLoops) > 0 && stepSize > int(c.wakeLoops[0]-gameLoop) {
+		return int(c.wakeLoops[0] - gameLoop)
+	}
+	return stepSize
+}
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/gogo/protobuf/proto"
+)
+
+// A tape is a recording of every request sent to the game along with the response that was
+// received. It is stored as a sequence of length-delimited TapeEntry messages.
+
+// TapeEntry is a single recorded request/response exchange.
+type TapeEntry struct {
+	Request  *api.Request  `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Response *api.Response `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	// Sent is the wall-clock time the request was sent in Unix nanoseconds.
+	Sent int64 `protobuf:"varint,3,opt,name=sent,proto3" json:"sent,omitempty"`
+	// Elapsed is the number of nanoseconds until the response was received.
+	Elapsed int64 `protobuf:"varint,4,opt,name=elapsed,proto3" json:"elapsed,omitempty"`
+}
+
+// Reset implements proto.Message.
+func (m *TapeEntry) Reset() { *m = TapeEntry{} }
+
+// String implements proto.Message.
+func (m *TapeEntry) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message.
+func (*TapeEntry) ProtoMessage() {}
+
+// SentTime returns Sent as a time.Time.
+func (m *TapeEntry) SentTime() time.Time {
+	return time.Unix(0, m.Sent)
+}
+
+// ElapsedTime returns Elapsed as a time.Duration.
+func (m *TapeEntry) ElapsedTime() time.Duration {
+	return time.Duration(m.Elapsed)
+}
+
+// tapeWriter appends entries to a tape as responses are received.
+type tapeWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+	log *slog.Logger
+}
+
+// record writes an entry using the already serialized request and response so neither
+// needs to be marshalled again. The layout matches the TapeEntry message.
+func (t *tapeWriter) record(req, resp []byte, sent time.Time, elapsed time.Duration) {
+	var entry proto.Buffer
+	entry.EncodeVarint(1<<3 | proto.WireBytes)
+	entry.EncodeRawBytes(req)
+	entry.EncodeVarint(2<<3 | proto.WireBytes)
+	entry.EncodeRawBytes(resp)
+	entry.EncodeVarint(3<<3 | proto.WireVarint)
+	entry.EncodeVarint(uint64(sent.UnixNano()))
+	entry.EncodeVarint(4<<3 | proto.WireVarint)
+	entry.EncodeVarint(uint64(elapsed))
+
+	var buf proto.Buffer
+	buf.EncodeRawBytes(entry.Bytes())
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return
+	}
+	if _, t.err = t.w.Write(buf.Bytes()); t.err != nil {
+		t.log.Error("tape recording stopped", "err", t.err)
+	}
+}
+
+// RecordTape starts recording every request and response to w. Call it before connecting
+// to capture the whole session, or pass nil to stop recording. The caller is responsible
+// for closing w once the client is done.
+func (c *Client) RecordTape(w io.Writer) {
+	c.connection.recorder = nil
+	if w != nil {
+		c.connection.recorder = &tapeWriter{w: w, log: c.Logger()}
+	}
+	if c.connection.pipe != nil {
+		c.connection.pipe.setRecorder(c.connection.recorder)
+	}
+}
+
+// ConnectTape connects the client to a recorded tape instead of a running game. Any setup
+// exchanges at the start of the tape (creating/joining a game or starting a replay) are
+// skipped and applied directly to the client so it is ready for Init. After that responses
+// are served from the tape in order and an error is returned if the client sends a request
+// of a different type than the one that was recorded.
+func (c *Client) ConnectTape(r io.Reader) error {
+	c.connection.Status = api.Status_unknown
+
+	t := newTapePlayer(r)
+	for {
+		entry, err := t.peek()
+		if err != nil {
+			return err
+		}
+
+		switch req := entry.Request.GetRequest().(type) {
+		case *api.Request_Ping:
+			// Pings are answered out of order, so their (stale) status is dropped
+			ping := *entry.Response
+			ping.Status = api.Status_nil
+			t.ping = &ping
+		case *api.Request_CreateGame:
+			c.realtime = req.CreateGame.GetRealtime()
+		case *api.Request_JoinGame:
+			c.playerID = entry.Response.GetJoinGame().GetPlayerId()
+			c.connection.game.playerID.Store(uint32(c.playerID))
+		case *api.Request_StartReplay:
+			c.realtime = req.StartReplay.GetRealtime()
+		case *api.Request_ReplayInfo:
+			c.replayInfo = entry.Response.GetReplayInfo()
+		default:
+			return c.connection.attach(t)
+		}
+		if status := entry.Response.GetStatus(); status != api.Status_nil {
+			c.connection.Status = status
+		}
+		t.peeked = nil
+	}
+}
+
+// TapeReader reads entries from a recorded tape.
+type TapeReader struct {
+	r *bufio.Reader
+}
+
+// NewTapeReader returns a reader for the tape data in r.
+func NewTapeReader(r io.Reader) *TapeReader {
+	return &TapeReader{bufio.NewReader(r)}
+}
+
+// Next returns the next entry on the tape, or io.EOF when there are no more.
+func (t *TapeReader) Next() (*TapeEntry, error) {
+	size, err := binary.ReadUvarint(t.r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(t.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	entry := &TapeEntry{}
+	if err := proto.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// tapePlayer is a transport that answers requests from a tape.
+type tapePlayer struct {
+	tape   *TapeReader
+	peeked *TapeEntry
+	ping   *api.Response // pings can be answered at any time
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	responses [][]byte
+	err       error
+}
+
+func newTapePlayer(r io.Reader) *tapePlayer {
+	t := &tapePlayer{tape: NewTapeReader(r)}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+func (t *tapePlayer) WriteMessage(data []byte) error {
+	req := &api.Request{}
+	err := proto.Unmarshal(data, req)
+	if err != nil {
+		return err
+	}
+
+	var entry *TapeEntry
+	if _, ok := req.Request.(*api.Request_Ping); ok && t.ping != nil {
+		entry = &TapeEntry{Request: req, Response: t.ping}
+	} else if entry, err = t.peek(); err != nil {
+		if err == io.EOF {
+			err = errors.New("end of tape")
+		}
+		t.close(err)
+		return err
+	} else {
+		t.peeked = nil
+	}
+
+	// The client should make exactly the same sequence of requests that was recorded
+	if got, want := reflect.TypeOf(req.Request), reflect.TypeOf(entry.Request.GetRequest()); got != want {
+		err = fmt.Errorf("tape mismatch: got %v, expected %v", got, want)
+		t.close(err)
+		return err
+	}
+
+	resp := &api.Response{}
+	if entry.Response != nil {
+		*resp = *entry.Response
+	}
+	resp.Id = req.Id
+
+	data, err = proto.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.responses = append(t.responses, data)
+	t.cond.Signal()
+	t.mu.Unlock()
+	return nil
+}
+
+// peek returns the next entry on the tape without consuming it.
+func (t *tapePlayer) peek() (*TapeEntry, error) {
+	if t.peeked == nil {
+		entry, err := t.tape.Next()
+		if err != nil {
+			return nil, err
+		}
+		t.peeked = entry
+	}
+	return t.peeked, nil
+}
+
+func (t *tapePlayer) ReadMessage() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for len(t.responses) == 0 && t.err == nil {
+		t.cond.Wait()
+	}
+	if len(t.responses) == 0 {
+		return nil, t.err
+	}
+
+	data := t.responses[0]
+	t.responses = t.responses[1:]
+	return data, nil
+}
+
+func (t *tapePlayer) Close() error {
+	t.close(io.EOF)
+	return nil
+}
+
+func (t *tapePlayer) close(err error) {
+	t.mu.Lock()
+	if t.err == nil {
+		t.err = err
+	}
+	t.cond.Broadcast()
+	t.mu.Unlock()
+}
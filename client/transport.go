@@ -0,0 +1,45 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/gorilla/websocket"
+)
+
+// Transport sends and receives whole protobuf messages to and from the game. Messages are
+// written and read in order, but a read and a write may be in progress at the same time.
+// Close must interrupt any blocked reads or writes.
+type Transport interface {
+	WriteMessage(data []byte) error
+	ReadMessage() ([]byte, error)
+	Close() error
+}
+
+// Dialer opens a Transport to the game at the given address and port.
+type Dialer func(address string, port int) (Transport, error)
+
+// DialWebsocket is the default Dialer which connects to ws://address:port/sc2api.
+func DialWebsocket(address string, port int) (Transport, error) {
+	dialer := websocket.Dialer{WriteBufferSize: MaxMessageSize}
+	url := fmt.Sprintf("ws://%v:%v/sc2api", address, port)
+
+	ws, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return wsTransport{ws}, nil
+}
+
+// wsTransport adapts a websocket connection to the Transport interface.
+type wsTransport struct {
+	*websocket.Conn
+}
+
+func (t wsTransport) WriteMessage(data []byte) error {
+	return t.Conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+func (t wsTransport) ReadMessage() ([]byte, error) {
+	_, data, err := t.Conn.ReadMessage()
+	return data, err
+}
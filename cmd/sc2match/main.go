@@ -0,0 +1,163 @@
+// Command sc2match plays a tournament between bot programs and computer opponents and
+// writes the results and per bot/map/race stats. In-process agents can use the tournament
+// package directly.
+//
+// Example:
+//
+//	sc2match -bot "ZergRush:Zerg:./zerg_rush" -bot "Reapers:Terran:./proxy_reapers" \
+//		-computers Easy,Medium -maps AcropolisLE,ThunderbirdLE -games 2 -concurrency 2
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+	"github.com/chippydip/go-sc2ai/runner"
+	"github.com/chippydip/go-sc2ai/tournament"
+)
+
+type botsFlag []client.PlayerSetup
+
+func (f *botsFlag) String() string {
+	return ""
+}
+
+func (f *botsFlag) Set(value string) error {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("expected Name:Race:command, got %q", value)
+	}
+	race, err := parseRace(parts[1])
+	if err != nil {
+		return err
+	}
+	command := strings.Fields(parts[2])
+	if len(command) == 0 {
+		return fmt.Errorf("missing command for %v", parts[0])
+	}
+	*f = append(*f, runner.NewBotProcess(race, parts[0], command...))
+	return nil
+}
+
+func main() {
+	var bots botsFlag
+	flag.Var(&bots, "bot", "A bot program as Name:Race:command [args], may be repeated")
+	computers := flag.String("computers", "", "Comma-separated computer difficulties to play against (e.g. Easy,Hard)")
+	computerRaces := flag.String("computerRaces", "Random", "Comma-separated races for each computer difficulty")
+	maps := flag.String("maps", "", "Comma-separated map pool (required)")
+	format := flag.String("format", "roundrobin", "Tournament format: roundrobin or series (the first bot plays everyone)")
+	games := flag.Int("games", 1, "Games per pairing on each map")
+	concurrency := flag.Int("concurrency", 1, "Number of games to play at the same time")
+	resultsPath := flag.String("results", "results.json", "Results file (.csv for CSV, JSON otherwise)")
+	statsPath := flag.String("stats", "", "Stats file (.csv for CSV, JSON otherwise)")
+	replayDir := flag.String("replays", "", "Directory to save replays to")
+	portStart := flag.Int("portStart", 8168, "First port to use, each concurrent game uses the next 20")
+	maxGameLoop := flag.Uint("maxGameLoop", 0, "Stop each game with no winner at this game loop (0 for no limit)")
+	timeout := flag.Duration("timeout", 0, "Stop each game that takes longer than this (0 for no limit)")
+	flag.Parse()
+	runner.LoadSettings()
+
+	t := tournament.Tournament{
+		Players:     bots,
+		Maps:        split(*maps),
+		Games:       *games,
+		Concurrency: *concurrency,
+		PortStart:   *portStart,
+		ReplayDir:   *replayDir,
+		MaxGameLoop: uint32(*maxGameLoop),
+		Timeout:     *timeout,
+	}
+
+	switch strings.ToLower(*format) {
+	case "roundrobin":
+		t.Format = tournament.RoundRobin
+	case "series":
+		t.Format = tournament.Series
+	default:
+		log.Fatalf("Unknown format: %v", *format)
+	}
+
+	races := split(*computerRaces)
+	for i, name := range split(*computers) {
+		difficulty, ok := api.Difficulty_value[name]
+		if !ok {
+			log.Fatalf("Unknown difficulty: %v", name)
+		}
+		race := api.Race_Random
+		if len(races) > 0 {
+			var err error
+			if race, err = parseRace(races[i%len(races)]); err != nil {
+				log.Fatal(err)
+			}
+		}
+		t.Players = append(t.Players, client.NewComputer(race, api.Difficulty(difficulty), api.AIBuild_RandomBuild))
+	}
+
+	if len(t.Maps) == 0 {
+		log.Fatal("No maps given, use -maps")
+	}
+	if len(t.Schedule()) == 0 {
+		log.Fatal("Nothing to play, add at least one -bot and another bot or computer")
+	}
+	if len(t.ReplayDir) > 0 {
+		if err := os.MkdirAll(t.ReplayDir, 0755); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	total := len(t.Schedule())
+	t.OnResult = func(r tournament.Result) {
+		outcome := r.Winner + " won"
+		switch {
+		case len(r.Error) > 0:
+			outcome = "error: " + r.Error
+		case len(r.Winner) == 0:
+			outcome = "tie"
+		}
+		log.Printf("[%v/%v] %v vs %v on %v: %v", r.Game, total, r.Players[0], r.Players[1], r.Map, outcome)
+	}
+
+	results := t.Run()
+	stats := tournament.Summarize(results)
+
+	if err := tournament.Save(*resultsPath, results); err != nil {
+		log.Fatal(err)
+	}
+	if len(*statsPath) > 0 {
+		if err := tournament.Save(*statsPath, stats); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Printf("%-20v %-16v %-8v %5v %5v %5v %5v %7v %6v %5v\n",
+		"Player", "Map", "VsRace", "Games", "Wins", "Loss", "Ties", "Crashes", "Win%", "Elo")
+	for _, s := range stats {
+		fmt.Printf("%-20v %-16v %-8v %5v %5v %5v %5v %7v %5.1f%% %5.0f\n",
+			s.Player, s.Map, s.Race, s.Games, s.Wins, s.Losses, s.Ties, s.Crashes, 100*s.WinRate, s.Elo)
+	}
+}
+
+func parseRace(value string) (api.Race, error) {
+	if len(value) > 0 {
+		value = strings.ToUpper(value[:1]) + value[1:]
+	}
+	if v, ok := api.Race_value[value]; ok {
+		return api.Race(v), nil
+	}
+	return 0, fmt.Errorf("Unknown race: %v", value)
+}
+
+func split(list string) []string {
+	var values []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+	return values
+}
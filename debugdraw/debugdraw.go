@@ -0,0 +1,126 @@
+// Package debugdraw provides named, toggleable layers of debug drawings that are merged into
+// a single debug request before each step.
+package debugdraw
+
+import (
+	"sort"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+// Drawer owns a set of named layers. Each layer is redrawn from scratch every step: anything
+// added to a layer is sent before the next step and then discarded. The game replaces all
+// debug drawings each time a draw command is received, so all enabled layers are merged into
+// a single command.
+type Drawer struct {
+	info   client.AgentInfo
+	layers map[string]*Layer
+	filter func(name string) bool
+	height api.ImageDataBytes
+	drawn  bool
+}
+
+// New creates a drawer that sends the enabled layers before each step.
+func New(info client.AgentInfo) *Drawer {
+	d := &Drawer{info: info, layers: map[string]*Layer{}}
+	if terrain := info.GameInfo().GetStartRaw().GetTerrainHeight(); terrain != nil {
+		d.height = terrain.Bytes()
+	}
+	info.OnBeforeStep(d.Send)
+	return d
+}
+
+// Layer returns the named layer, creating it (enabled) if it doesn't exist yet.
+func (d *Drawer) Layer(name string) *Layer {
+	l, ok := d.layers[name]
+	if !ok {
+		l = &Layer{d: d, name: name, enabled: true}
+		d.layers[name] = l
+	}
+	return l
+}
+
+// SetEnabled turns a layer on or off.
+func (d *Drawer) SetEnabled(name string, enabled bool) {
+	d.Layer(name).enabled = enabled
+}
+
+// Toggle flips whether a layer is enabled.
+func (d *Drawer) Toggle(name string) {
+	l := d.Layer(name)
+	l.enabled = !l.enabled
+}
+
+// SetFilter sets an additional check for whether each layer should be drawn, e.g. the
+// botutil.Commands DrawEnabled method so layers can be toggled from chat.
+func (d *Drawer) SetFilter(filter func(name string) bool) {
+	d.filter = filter
+}
+
+// Enabled returns true if the named layer will be drawn.
+func (d *Drawer) Enabled(name string) bool {
+	l, ok := d.layers[name]
+	if ok && !l.enabled {
+		return false
+	}
+	return d.filter == nil || d.filter(name)
+}
+
+// Names returns the names of all layers in sorted order.
+func (d *Drawer) Names() []string {
+	names := make([]string, 0, len(d.layers))
+	for name := range d.layers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Send merges all enabled layers into one draw command and sends it, then clears every layer
+// for the next step. It is called automatically before each step. If nothing is drawn but
+// something was drawn last time the previous drawings are cleared.
+func (d *Drawer) Send() {
+	draw := &api.DebugDraw{}
+	for _, name := range d.Names() {
+		l := d.layers[name]
+		if d.Enabled(name) {
+			draw.Text = append(draw.Text, l.draw.Text...)
+			draw.Lines = append(draw.Lines, l.draw.Lines...)
+			draw.Boxes = append(draw.Boxes, l.draw.Boxes...)
+			draw.Spheres = append(draw.Spheres, l.draw.Spheres...)
+		}
+		l.Clear()
+	}
+
+	if len(draw.Text)+len(draw.Lines)+len(draw.Boxes)+len(draw.Spheres) == 0 {
+		if d.drawn {
+			d.info.ClearDebugDraw()
+			d.drawn = false
+		}
+		return
+	}
+
+	err := d.info.SendDebugCommands([]*api.DebugCommand{{
+		Command: &api.DebugCommand_Draw{Draw: draw},
+	}})
+	if err != nil {
+		d.info.Logger().Error("Failed to send debug draw", "err", err)
+	}
+	d.drawn = true
+}
+
+// Height returns the terrain height at a world position (slightly raised so drawings aren't
+// hidden by the ground).
+func (d *Drawer) Height(pos api.Point2D) float32 {
+	x, y := int32(pos.X), int32(pos.Y)
+	if !d.height.InBounds(x, y) {
+		return 0
+	}
+	return (float32(d.height.Get(x, y))-127)/8 + 0.1
+}
+
+// point converts a world position to 3D using the terrain height.
+func (d *Drawer) point(pos api.Point2D) *api.Point {
+	return &api.Point{X: pos.X, Y: pos.Y, Z: d.Height(pos)}
+}
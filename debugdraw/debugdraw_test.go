@@ -0,0 +1,46 @@
+package debugdraw_test
+
+import (
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/debugdraw"
+	"github.com/chippydip/go-sc2ai/sc2test"
+)
+
+func TestDrawer(t *testing.T) {
+	var draws []*api.DebugDraw
+	s := &sc2test.Server{
+		Debug: func(r *api.RequestDebug) *api.ResponseDebug {
+			for _, cmd := range r.Debug {
+				draws = append(draws, cmd.GetDraw())
+			}
+			return &api.ResponseDebug{}
+		},
+	}
+	s.Start()
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+
+	d := debugdraw.New(c)
+	d.Layer("paths").Arrow(api.Point2D{X: 1, Y: 1}, api.Point2D{X: 5, Y: 1}, debugdraw.Green)
+	d.Layer("targets").Circle(api.Point2D{X: 3, Y: 3}, 1, debugdraw.Red)
+	d.Layer("hidden").Text(api.Point2D{X: 3, Y: 3}, "hidden", debugdraw.White)
+	d.SetEnabled("hidden", false)
+
+	if err := c.Step(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(draws) != 1 || len(draws[0].Lines) != 3 || len(draws[0].Spheres) != 1 || len(draws[0].Text) != 0 {
+		t.Fatalf("draws = %v", draws)
+	}
+
+	// Nothing drawn this step clears the previous drawings
+	if err := c.Step(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(draws) != 2 || len(draws[1].Lines)+len(draws[1].Spheres) != 0 {
+		t.Fatalf("draws = %v", draws)
+	}
+}
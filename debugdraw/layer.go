@@ -0,0 +1,156 @@
+package debugdraw
+
+import (
+	"github.com/chippydip/go-sc2ai/api"
+)
+
+// Common colors
+var (
+	White   = api.Color{R: 255, G: 255, B: 255}
+	Gray    = api.Color{R: 128, G: 128, B: 128}
+	Red     = api.Color{R: 255, G: 0, B: 0}
+	Green   = api.Color{R: 0, G: 255, B: 0}
+	Blue    = api.Color{R: 0, G: 0, B: 255}
+	Yellow  = api.Color{R: 255, G: 255, B: 0}
+	Cyan    = api.Color{R: 0, G: 255, B: 255}
+	Magenta = api.Color{R: 255, G: 0, B: 255}
+)
+
+// Gradient maps a byte value to a color for heatmaps.
+type Gradient func(v byte) api.Color
+
+// Heat is a gradient from blue (low) through green to red (high).
+func Heat(v byte) api.Color {
+	if v < 128 {
+		return api.Color{R: 0, G: uint32(v) * 2, B: 255 - uint32(v)*2}
+	}
+	return api.Color{R: (uint32(v) - 128) * 2, G: 255 - (uint32(v)-128)*2, B: 0}
+}
+
+// Layer collects drawings for a single step. Positions are in world coordinates and drawn
+// just above the terrain unless otherwise noted.
+type Layer struct {
+	d       *Drawer
+	name    string
+	enabled bool
+	draw    api.DebugDraw
+}
+
+// Name returns the layer's name.
+func (l *Layer) Name() string {
+	return l.name
+}
+
+// Enabled returns true if the layer will be drawn. Skipping expensive drawing code when it
+// isn't is recommended.
+func (l *Layer) Enabled() bool {
+	return l.d.Enabled(l.name)
+}
+
+// Clear discards everything drawn on the layer this step.
+func (l *Layer) Clear() {
+	l.draw = api.DebugDraw{}
+}
+
+// Text draws text at a world position.
+func (l *Layer) Text(pos api.Point2D, text string, color api.Color) {
+	l.draw.Text = append(l.draw.Text, &api.DebugText{Color: &color, Text: text, WorldPos: l.d.point(pos)})
+}
+
+// UnitText draws text on a unit.
+func (l *Layer) UnitText(u *api.Unit, text string, color api.Color) {
+	pos := *u.Pos
+	l.draw.Text = append(l.draw.Text, &api.DebugText{Color: &color, Text: text, WorldPos: &pos})
+}
+
+// ScreenText draws text at a fixed screen position, where (0, 0) is the top left and (1, 1)
+// is the bottom right of the screen.
+func (l *Layer) ScreenText(x, y float32, text string, color api.Color) {
+	l.draw.Text = append(l.draw.Text, &api.DebugText{Color: &color, Text: text, VirtualPos: &api.Point{X: x, Y: y}})
+}
+
+// Circle draws a circle (really a sphere) around a world position.
+func (l *Layer) Circle(pos api.Point2D, radius float32, color api.Color) {
+	l.draw.Spheres = append(l.draw.Spheres, &api.DebugSphere{Color: &color, P: l.d.point(pos), R: radius})
+}
+
+// UnitCircle draws a circle around a unit, a radius of zero uses the unit's radius.
+func (l *Layer) UnitCircle(u *api.Unit, radius float32, color api.Color) {
+	if radius == 0 {
+		radius = u.Radius
+	}
+	pos := *u.Pos
+	l.draw.Spheres = append(l.draw.Spheres, &api.DebugSphere{Color: &color, P: &pos, R: radius})
+}
+
+// Box draws a box between two corners, one unit tall.
+func (l *Layer) Box(min, max api.Point2D, color api.Color) {
+	lo := l.d.point(min)
+	hi := &api.Point{X: max.X, Y: max.Y, Z: lo.Z + 1}
+	l.draw.Boxes = append(l.draw.Boxes, &api.DebugBox{Color: &color, Min: lo, Max: hi})
+}
+
+// Line draws a line between two world positions.
+func (l *Layer) Line(p0, p1 api.Point2D, color api.Color) {
+	l.line(l.d.point(p0), l.d.point(p1), color)
+}
+
+// Arrow draws a line from p0 to p1 with an arrowhead at p1.
+func (l *Layer) Arrow(p0, p1 api.Point2D, color api.Color) {
+	l.Line(p0, p1, color)
+
+	dir := p0.DirTo(p1)
+	length := p0.Distance(p1)
+	if length == 0 {
+		return
+	}
+	size := length / 4
+	if size > 1 {
+		size = 1
+	}
+	back := p1.Add(dir.Mul(-size))
+	side := api.Vec2D{X: -dir.Y, Y: dir.X}.Mul(size / 2)
+	l.Line(p1, back.Add(side), color)
+	l.Line(p1, back.Add(side.Neg()), color)
+}
+
+func (l *Layer) line(p0, p1 *api.Point, color api.Color) {
+	l.draw.Lines = append(l.draw.Lines, &api.DebugLine{Color: &color, Line: &api.Line{P0: p0, P1: p1}})
+}
+
+// Heatmap draws a flat colored square for every non-zero cell of img, where cell (x, y)
+// covers the world area from (x, y) to (x+1, y+1) like the game's own grids. A nil gradient
+// uses Heat. Every cell is a separate box, so crop large images to the area of interest.
+func (l *Layer) Heatmap(img api.ImageDataBytes, gradient Gradient) {
+	if gradient == nil {
+		gradient = Heat
+	}
+	for y := int32(0); y < img.Height(); y++ {
+		for x := int32(0); x < img.Width(); x++ {
+			if v := img.Get(x, y); v != 0 {
+				l.cell(x, y, gradient(v))
+			}
+		}
+	}
+}
+
+// Grid draws the outline of every non-zero cell of img in a single color.
+func (l *Layer) Grid(img api.ImageDataBytes, color api.Color) {
+	for y := int32(0); y < img.Height(); y++ {
+		for x := int32(0); x < img.Width(); x++ {
+			if img.Get(x, y) != 0 {
+				l.cell(x, y, color)
+			}
+		}
+	}
+}
+
+// cell draws a flat box covering a single grid cell.
+func (l *Layer) cell(x, y int32, color api.Color) {
+	z := l.d.Height(api.Point2D{X: float32(x) + 0.5, Y: float32(y) + 0.5})
+	l.draw.Boxes = append(l.draw.Boxes, &api.DebugBox{
+		Color: &color,
+		Min:   &api.Point{X: float32(x) + 0.05, Y: float32(y) + 0.05, Z: z},
+		Max:   &api.Point{X: float32(x) + 0.95, Y: float32(y) + 0.95, Z: z},
+	})
+}
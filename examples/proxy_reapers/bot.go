@@ -138,7 +138,10 @@ func (bot *bot) strategy() {
 				scv.OrderPos(ability.Move, bot.positionsForBarracks)
 			} else {
 				// Query target build locations and use the first one that's available
-				results := bot.barracksQuery.Execute()
+				results, err := bot.barracksQuery.Execute()
+				if err != nil {
+					log.Print(err)
+				}
 				for i, result := range results.Placements() {
 					if result.Result == api.ActionResult_Success {
 						scv.BuildUnitAt(ability.Build_Barracks, *results.PlacementQuery(i).TargetPos)
@@ -31,7 +31,7 @@ func runAgent(info client.AgentInfo) {
 	for bot.IsInGame() {
 		bot.doSmt()
 
-		if err := bot.Step(1); err != nil {
+		if err := bot.AutoStep(); err != nil {
 			log.Print(err)
 			break
 		}
@@ -56,7 +56,10 @@ func (bot *bot) init() {
 			EndPos: &pos,
 		}
 	}
-	resp := bot.Query(api.RequestQuery{Pathing: query})
+	resp, err := bot.Query(api.RequestQuery{Pathing: query})
+	if err != nil {
+		log.Print(err)
+	}
 	best, minDist := -1, float32(256)
 	for i, result := range resp.GetPathing() {
 		if result.Distance < minDist && result.Distance > 5 {
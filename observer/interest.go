@@ -0,0 +1,175 @@
+package observer
+
+import (
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/enums/protoss"
+	"github.com/chippydip/go-sc2ai/enums/terran"
+	"github.com/chippydip/go-sc2ai/enums/zerg"
+)
+
+// Scoring weights for the different kinds of action.
+const (
+	armyWeight     = 1    // per resource value of army units facing an enemy army
+	damageWeight   = 20   // per point of health or shields lost
+	deathWeight    = 2    // per resource value of units that died
+	baseWeight     = 1.5  // multiplier for fights near structures
+	dropWeight     = 400  // per loaded transport near enemy structures
+	interestRadius = 12.0 // world units around a unit that count as the same action
+)
+
+// hotspot is a point of interest and how interesting it is.
+type hotspot struct {
+	pos    api.Point2D
+	score  float64
+	player api.PlayerID // the player with the most value here
+}
+
+// scoredUnit is a player unit along with the values needed for scoring.
+type scoredUnit struct {
+	*api.Unit
+	value     float64
+	structure bool
+	interest  float64
+}
+
+// scorer tracks state between observations so damage and deaths can be detected.
+type scorer struct {
+	prev  map[api.UnitTag]*api.Unit
+	units []*scoredUnit // scored units from the latest observation
+}
+
+// score finds the most interesting location in the observation. Interest comes from opposing
+// armies near each other, damage taken and units dying since the last observation, fights
+// near structures and loaded transports near enemy structures (drops).
+func (s *scorer) score(obs *api.Observation, data *api.ResponseData) hotspot {
+	raw := obs.GetRawData()
+
+	var units []*scoredUnit
+	for _, u := range raw.GetUnits() {
+		if u.Alliance != api.Alliance_Neutral {
+			units = append(units, newScoredUnit(u, data))
+		}
+	}
+
+	for _, u := range units {
+		// Damage taken since the last observation
+		if p, ok := s.prev[u.Tag]; ok {
+			if lost := (p.Health - u.Health) + (p.Shield - u.Shield); lost > 0 {
+				u.interest += float64(lost) * damageWeight
+			}
+		}
+
+		// Army units close to an opposing army
+		if !u.structure && u.near(units, func(v *scoredUnit) bool { return v.Owner != u.Owner && !v.structure }) {
+			u.interest += u.value * armyWeight
+		}
+
+		// Drops
+		if isLoadedTransport(u.Unit) && u.near(units, func(v *scoredUnit) bool { return v.Owner != u.Owner && v.structure }) {
+			u.interest += dropWeight
+		}
+	}
+
+	// Units that just died make their area interesting for a moment
+	for _, tag := range raw.GetEvent().GetDeadUnits() {
+		if p, ok := s.prev[tag]; ok {
+			u := newScoredUnit(p, data)
+			u.interest = u.value * deathWeight
+			units = append(units, u)
+		}
+	}
+
+	s.prev = make(map[api.UnitTag]*api.Unit, len(raw.GetUnits()))
+	for _, u := range raw.GetUnits() {
+		s.prev[u.Tag] = u
+	}
+	s.units = units
+
+	// Find the unit with the most interest around it
+	best := hotspot{}
+	for _, u := range units {
+		if u.interest == 0 {
+			continue
+		}
+		if h := s.around(u.Pos.ToPoint2D()); h.score > best.score {
+			best = h
+		}
+	}
+	return best
+}
+
+// scoreAt returns how interesting the area around pos was in the latest observation.
+func (s *scorer) scoreAt(pos api.Point2D) float64 {
+	return s.around(pos).score
+}
+
+// around scores the units within interestRadius of center. The hotspot is positioned at the
+// interest-weighted center of those units.
+func (s *scorer) around(center api.Point2D) hotspot {
+	var total, x, y float64
+	value := map[api.PlayerID]float64{}
+	structures := false
+	for _, v := range s.units {
+		if center.Distance2(v.Pos.ToPoint2D()) >= interestRadius*interestRadius {
+			continue
+		}
+		structures = structures || v.structure
+		value[v.Owner] += v.value
+		total += v.interest
+		x += float64(v.Pos.X) * v.interest
+		y += float64(v.Pos.Y) * v.interest
+	}
+	if total == 0 {
+		return hotspot{pos: center}
+	}
+
+	h := hotspot{pos: api.Point2D{X: float32(x / total), Y: float32(y / total)}, score: total}
+	if structures {
+		h.score *= baseWeight
+	}
+	for p, v := range value {
+		if h.player == 0 || v > value[h.player] {
+			h.player = p
+		}
+	}
+	return h
+}
+
+func newScoredUnit(u *api.Unit, data *api.ResponseData) *scoredUnit {
+	su := &scoredUnit{Unit: u, value: 25} // minimum so free units still count
+	if int(u.UnitType) < len(data.GetUnits()) {
+		d := data.Units[u.UnitType]
+		if v := float64(d.MineralCost + d.VespeneCost); v > su.value {
+			su.value = v
+		}
+		for _, attr := range d.Attributes {
+			if attr == api.Attribute_Structure {
+				su.structure = true
+			}
+		}
+	}
+	return su
+}
+
+// near returns true if any unit within interestRadius matches the filter.
+func (u *scoredUnit) near(units []*scoredUnit, filter func(*scoredUnit) bool) bool {
+	for _, v := range units {
+		if filter(v) && dist2(u.Unit, v.Unit) < interestRadius*interestRadius {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoadedTransport(u *api.Unit) bool {
+	switch u.UnitType {
+	case terran.Medivac, protoss.WarpPrism, protoss.WarpPrismPhasing, zerg.OverlordTransport:
+		return u.CargoSpaceTaken > 0
+	}
+	return false
+}
+
+func dist2(a, b *api.Unit) float64 {
+	dx, dy := float64(a.Pos.X-b.Pos.X), float64(a.Pos.Y-b.Pos.Y)
+	return dx*dx + dy*dy
+}
@@ -0,0 +1,42 @@
+package observer
+
+import (
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/enums/terran"
+	"github.com/chippydip/go-sc2ai/enums/zerg"
+)
+
+func TestScore(t *testing.T) {
+	unit := func(tag api.UnitTag, owner api.PlayerID, unitType api.UnitTypeID, x, y, health float32) *api.Unit {
+		return &api.Unit{Tag: tag, Owner: owner, Alliance: api.Alliance_Self, UnitType: unitType,
+			Pos: &api.Point{X: x, Y: y}, Health: health}
+	}
+	observe := func(units ...*api.Unit) *api.Observation {
+		return &api.Observation{RawData: &api.ObservationRaw{Units: units}}
+	}
+
+	s := scorer{}
+	idle := s.score(observe(
+		unit(1, 1, terran.Marine, 20, 20, 45),
+		unit(2, 2, zerg.Zergling, 80, 80, 35),
+	), nil)
+	if idle.score != 0 {
+		t.Errorf("idle score = %v, want 0", idle.score)
+	}
+
+	s.score(observe(
+		unit(1, 1, terran.Marine, 50, 50, 45),
+		unit(2, 2, zerg.Zergling, 52, 50, 35),
+		unit(3, 2, zerg.Zergling, 54, 50, 35),
+	), nil)
+	fight := s.score(observe(
+		unit(1, 1, terran.Marine, 50, 50, 40),
+		unit(2, 2, zerg.Zergling, 52, 50, 29),
+		unit(3, 2, zerg.Zergling, 54, 50, 35),
+	), nil)
+	if fight.score == 0 || fight.pos.Distance(api.Point2D{X: 52, Y: 50}) > 2 || fight.player != 2 {
+		t.Errorf("fight = %+v", fight)
+	}
+}
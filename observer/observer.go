@@ -0,0 +1,140 @@
+// Package observer implements an automatic camera for observing replays and live games.
+package observer
+
+import (
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+// Observer moves the camera to the most interesting action in the game. Battles, fights near
+// bases and drops are scored from the raw observation each step. The camera glides toward
+// the current target and only switches to a new one once it has been watched for a while and
+// the new one is clearly more interesting. The player perspective follows whichever player
+// has the most at stake in the current target.
+type Observer struct {
+	info client.AgentInfo
+
+	// StepSize is the number of game loops between camera updates.
+	StepSize int
+	// MinDwell is the number of game loops to stay on a target before switching to another.
+	MinDwell uint32
+	// SwitchRatio is how much more interesting a new target must be to switch to it.
+	SwitchRatio float64
+	// Smoothing is the fraction of the remaining distance the camera moves each update (0-1].
+	Smoothing float32
+	// Distance is the camera zoom distance, or 0 for the default.
+	Distance float32
+	// SwitchPerspective enables changing the player perspective along with the target.
+	SwitchPerspective bool
+
+	scorer      scorer
+	target      hotspot
+	targetSince uint32
+	camera      api.Point2D
+	perspective api.PlayerID
+}
+
+// New creates an observer with reasonable defaults. The camera starts at the first player's
+// start location (or the center of the map).
+func New(info client.AgentInfo) *Observer {
+	o := &Observer{
+		info:              info,
+		StepSize:          4,
+		MinDwell:          224 * 4, // 4 seconds
+		SwitchRatio:       1.5,
+		Smoothing:         0.2,
+		SwitchPerspective: true,
+	}
+
+	start := info.GameInfo().GetStartRaw()
+	if locs := start.GetStartLocations(); len(locs) > 0 {
+		o.camera = *locs[0]
+	} else if area := start.GetPlayableArea(); area != nil {
+		o.camera = api.Point2D{X: float32(area.P0.X+area.P1.X) / 2, Y: float32(area.P0.Y+area.P1.Y) / 2}
+	}
+	o.target.pos = o.camera
+	return o
+}
+
+// Agent returns a client.Agent that observes until the game ends.
+func Agent() client.Agent {
+	return client.AgentFunc(func(info client.AgentInfo) {
+		o := New(info)
+		for info.IsInGame() {
+			o.Update()
+			if err := info.Step(o.StepSize); err != nil {
+				info.Logger().Error("Observer step failed", "err", err)
+				break
+			}
+		}
+	})
+}
+
+// Target returns the location the camera is moving toward.
+func (o *Observer) Target() api.Point2D {
+	return o.target.pos
+}
+
+// Camera returns the current camera location.
+func (o *Observer) Camera() api.Point2D {
+	return o.camera
+}
+
+// Update scores the latest observation and sends camera and perspective actions.
+func (o *Observer) Update() {
+	obs := o.info.Observation().GetObservation()
+	loop := obs.GetGameLoop()
+	best := o.scorer.score(obs, o.info.Data())
+
+	// Rescore the current target so a fight that ended doesn't keep its old score (it is zero
+	// once nothing is happening there, which allows switching as soon as anything else does)
+	o.target.score = o.scorer.scoreAt(o.target.pos)
+
+	// Hysteresis: keep watching the current target unless it has been watched long enough
+	// (or has gone quiet) and the new one is clearly better.
+	if best.score > 0 {
+		near := best.pos.Distance(o.target.pos) < interestRadius
+		dwelled := loop-o.targetSince >= o.MinDwell || o.target.score == 0
+		switch {
+		case near:
+			o.target.pos, o.target.score = best.pos, best.score
+			if best.player != 0 {
+				o.target.player = best.player
+			}
+		case dwelled && best.score > o.target.score*o.SwitchRatio:
+			o.target, o.targetSince = best, loop
+		}
+	}
+
+	var actions []*api.ObserverAction
+	if o.SwitchPerspective && o.target.player != 0 && o.target.player != o.perspective {
+		o.perspective = o.target.player
+		actions = append(actions, &api.ObserverAction{
+			Action: &api.ObserverAction_PlayerPerspective{
+				PlayerPerspective: &api.ActionObserverPlayerPerspective{PlayerId: o.perspective},
+			},
+		})
+	}
+
+	if d := o.camera.Distance(o.target.pos); d > 0.5 {
+		step := d * o.Smoothing
+		if o.Smoothing <= 0 || o.Smoothing > 1 || d < 1 {
+			step = d
+		} else if step < 1 {
+			step = 1 // don't crawl the last little bit
+		}
+		o.camera = o.camera.Offset(o.target.pos, step)
+		pos := o.camera
+		actions = append(actions, &api.ObserverAction{
+			Action: &api.ObserverAction_CameraMove{
+				CameraMove: &api.ActionObserverCameraMove{WorldPos: &pos, Distance: o.Distance},
+			},
+		})
+	}
+
+	if len(actions) > 0 {
+		if err := o.info.SendObserverActions(actions); err != nil {
+			o.info.Logger().Warn("Failed to send observer actions", "err", err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package observer
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+	"github.com/chippydip/go-sc2ai/enums/terran"
+	"github.com/chippydip/go-sc2ai/enums/zerg"
+)
+
+// fakeInfo implements the parts of client.AgentInfo used by the Observer.
+type fakeInfo struct {
+	client.AgentInfo
+	obs *api.ResponseObservation
+}
+
+func (i *fakeInfo) Observation() *api.ResponseObservation             { return i.obs }
+func (i *fakeInfo) Data() *api.ResponseData                           { return nil }
+func (i *fakeInfo) GameInfo() *api.ResponseGameInfo                   { return &api.ResponseGameInfo{} }
+func (i *fakeInfo) SendObserverActions(a []*api.ObserverAction) error { return nil }
+func (i *fakeInfo) Logger() *slog.Logger                              { return slog.Default() }
+
+// fight returns n marines facing n zerglings around (x, y).
+func fight(tag api.UnitTag, n int, x, y float32) []*api.Unit {
+	var units []*api.Unit
+	for i := 0; i < n; i++ {
+		units = append(units,
+			&api.Unit{Tag: tag + api.UnitTag(2*i), Owner: 1, UnitType: terran.Marine, Pos: &api.Point{X: x, Y: y + float32(i)}},
+			&api.Unit{Tag: tag + api.UnitTag(2*i+1), Owner: 2, UnitType: zerg.Zergling, Pos: &api.Point{X: x + 2, Y: y + float32(i)}})
+	}
+	return units
+}
+
+func TestUpdateHysteresis(t *testing.T) {
+	info := &fakeInfo{}
+	loop := uint32(0)
+	update := func(o *Observer, fights ...[]*api.Unit) {
+		var units []*api.Unit
+		for _, f := range fights {
+			units = append(units, f...)
+		}
+		loop++
+		info.obs = &api.ResponseObservation{Observation: &api.Observation{
+			GameLoop: loop,
+			RawData:  &api.ObservationRaw{Units: units},
+		}}
+		o.Update()
+	}
+	near := func(o *Observer, x, y float32) bool {
+		return o.Target().Distance(api.Point2D{X: x, Y: y}) < interestRadius
+	}
+
+	o := New(info)
+	o.MinDwell = 8
+
+	a, b, c := fight(100, 4, 50, 50), fight(200, 1, 100, 100), fight(300, 10, 150, 150)
+	update(o, a)
+	if !near(o, 50, 50) {
+		t.Fatalf("target = %v, want the first fight", o.Target())
+	}
+
+	// A much bigger fight doesn't take over until the current one has been watched a while
+	update(o, a, c)
+	if !near(o, 50, 50) {
+		t.Errorf("target = %v, switched before MinDwell", o.Target())
+	}
+	for i := 0; i < 8; i++ {
+		update(o, a, c)
+	}
+	if !near(o, 150, 150) {
+		t.Fatalf("target = %v, want the bigger fight", o.Target())
+	}
+
+	// A smaller fight isn't enough to switch away from an ongoing one
+	for i := 0; i < 10; i++ {
+		update(o, b, c)
+	}
+	if !near(o, 150, 150) {
+		t.Errorf("target = %v, switched to a smaller fight", o.Target())
+	}
+
+	// Once the watched fight is over the smaller one is shown
+	update(o, b)
+	if !near(o, 100, 100) {
+		t.Errorf("target = %v, still watching a fight that ended", o.Target())
+	}
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+var (
+	budgetPerStep     = time.Duration(0)
+	budgetWindow      = 0
+	budgetWindowLimit = time.Duration(0)
+	budgetBank        = time.Duration(0)
+	budgetBankRefill  = time.Duration(0)
+	budgetPolicy      = "log"
+)
+
+func init() {
+	flagDur("stepLimit", &budgetPerStep, "Maximum time the bot may take per step (zero disables the check)")
+	flagInt("stepWindow", &budgetWindow, "Number of recent steps that stepWindowLimit applies to")
+	flagDur("stepWindowLimit", &budgetWindowLimit, "Maximum total time the bot may take over the last stepWindow steps (zero disables the check)")
+	flagDur("timeBank", &budgetBank, "Extra time that step limit overruns are taken from before they count")
+	flagDur("timeBankRefill", &budgetBankRefill, "Time added back to the time bank after every step")
+	flagStr("stepPolicy", &budgetPolicy, "What to do when the bot is over its step time budget: log, skip or leave")
+}
+
+// SetStepBudget sets the default per-step time limit, time bank and overrun policy.
+func SetStepBudget(perStep, timeBank time.Duration, policy string) {
+	Set("stepLimit", perStep.String())
+	Set("timeBank", timeBank.String())
+	Set("stepPolicy", policy)
+}
+
+// SetStepWindow sets the default limit on the total time taken over the last steps.
+func SetStepWindow(steps int, limit time.Duration) {
+	Set("stepWindow", strconv.Itoa(steps))
+	Set("stepWindowLimit", limit.String())
+}
+
+// SetTimeBankRefill sets the default time added back to the time bank after every step.
+func SetTimeBankRefill(refill time.Duration) {
+	Set("timeBankRefill", refill.String())
+}
+
+// stepBudget returns the budget configured by the command line flags.
+func stepBudget() client.StepBudget {
+	if budgetPerStep == 0 && (budgetWindow == 0 || budgetWindowLimit == 0) {
+		return client.StepBudget{}
+	}
+
+	budget := client.StepBudget{
+		PerStep:     budgetPerStep,
+		Window:      budgetWindow,
+		WindowLimit: budgetWindowLimit,
+		TimeBank:    budgetBank,
+		BankRefill:  budgetBankRefill,
+	}
+	switch strings.ToLower(budgetPolicy) {
+	case "log":
+		budget.Policy = client.BudgetLog
+	case "skip":
+		budget.Policy = client.BudgetSkip
+	case "leave":
+		budget.Policy = client.BudgetLeave
+	default:
+		slog.Warn("Unknown step policy", "policy", budgetPolicy)
+	}
+	return budget
+}
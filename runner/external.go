@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+var (
+	resultFilePath = ""
+)
+
+func init() {
+	flagStr("resultFile", &resultFilePath, "Write the game result to this JSON file when the game is over")
+}
+
+// BotProcess is a bot that runs as a separate program. RunMatch starts it with the ladder
+// flags (--GamePort, --StartPort, --LadderServer and --OpponentId) so it joins the game just
+// like it would on the ladder, plus --resultFile to report the result. Bots using this
+// runner support all of them, any other bot is counted as crashed if it doesn't write the
+// result file.
+type BotProcess struct {
+	// Command is the program to run followed by any extra arguments.
+	Command []string
+	// Dir is the working directory of the program (the current directory if empty).
+	Dir string
+	// Output receives the program's stdout and stderr (discarded if nil).
+	Output io.Writer
+}
+
+// NewBotProcess returns the player setup for a bot program.
+func NewBotProcess(race api.Race, name string, command ...string) client.PlayerSetup {
+	return client.NewParticipant(race, &BotProcess{Command: command}, name)
+}
+
+// RunAgent is never called, RunMatch starts the program instead.
+func (b *BotProcess) RunAgent(info client.AgentInfo) {
+	panic("runner: a BotProcess can only be run by RunMatch")
+}
+
+// botResult is the contents of the result file.
+type botResult struct {
+	Result   string `json:"result"`
+	GameLoop uint32 `json:"game_loop"`
+	Crashed  bool   `json:"crashed"`
+}
+
+// writeResultFile writes the client's result if the -resultFile flag was given.
+func writeResultFile(c *client.Client, crashed bool) {
+	if len(resultFilePath) == 0 {
+		return
+	}
+
+	r := botResult{
+		Result:   api.Result_Undecided.String(),
+		GameLoop: c.Observation().GetObservation().GetGameLoop(),
+		Crashed:  crashed,
+	}
+	for _, player := range c.Observation().GetPlayerResult() {
+		if player.GetPlayerId() == c.PlayerID() {
+			r.Result = player.GetResult().String()
+		}
+	}
+
+	data, err := json.Marshal(r)
+	if err == nil {
+		err = os.WriteFile(resultFilePath, data, 0644)
+	}
+	if err != nil {
+		c.Logger().Error("Unable to write result file", "path", resultFilePath, "err", err)
+	}
+}
+
+// botRun is a running BotProcess.
+type botRun struct {
+	cmd    *exec.Cmd
+	dir    string
+	killed atomic.Bool
+}
+
+// start runs the program so it joins the game on port, using the same game ports as the
+// rest of config.
+func (b *BotProcess) start(config *gameConfig, port int, opponent string) (*botRun, error) {
+	if len(b.Command) == 0 {
+		return nil, errors.New("runner: BotProcess has no command")
+	}
+
+	dir, err := os.MkdirTemp("", "sc2bot")
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(b.Command[1:len(b.Command):len(b.Command)],
+		"--GamePort", strconv.Itoa(port),
+		"--StartPort", strconv.Itoa(config.lastPort),
+		"--LadderServer", config.netAddress,
+		"--OpponentId", opponent,
+		"--resultFile", filepath.Join(dir, "result.json"),
+	)
+	cmd := exec.Command(b.Command[0], args...)
+	cmd.Dir = b.Dir
+	cmd.Stdout, cmd.Stderr = b.Output, b.Output
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("runner: unable to start %v: %v", b.Command[0], err)
+	}
+	return &botRun{cmd: cmd, dir: dir}, nil
+}
+
+// wait waits for the program to exit and returns its result. A program that was killed
+// isn't counted as crashed.
+func (r *botRun) wait() botResult {
+	defer os.RemoveAll(r.dir)
+
+	exitErr := r.cmd.Wait()
+	killed := r.killed.Load()
+
+	result := botResult{Result: api.Result_Undecided.String(), Crashed: !killed}
+	if data, err := os.ReadFile(filepath.Join(r.dir, "result.json")); err == nil {
+		result.Crashed = false
+		if err := json.Unmarshal(data, &result); err != nil {
+			result.Crashed = true
+		}
+	}
+	if exitErr != nil && !killed {
+		result.Crashed = true
+	}
+	return result
+}
+
+// kill stops the program if it is still running.
+func (r *botRun) kill() {
+	r.killed.Store(true)
+	if r.cmd.Process != nil {
+		r.cmd.Process.Kill()
+	}
+}
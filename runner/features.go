@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/chippydip/go-sc2ai/api"
+)
+
+var (
+	featureScreen  = 0
+	featureMinimap = 64
+	featureWidth   = 24
+	renderScreen   = 0
+	renderMinimap  = 128
+)
+
+func init() {
+	flagInt("featureScreen", &featureScreen, "Screen resolution of the feature layers (zero disables them)")
+	flagInt("featureMinimap", &featureMinimap, "Minimap resolution of the feature layers")
+	flagInt("featureWidth", &featureWidth, "Width of the feature layer camera in game units")
+	flagInt("renderScreen", &renderScreen, "Screen resolution of the rendered observation (zero disables it)")
+	flagInt("renderMinimap", &renderMinimap, "Minimap resolution of the rendered observation")
+}
+
+// SetFeatureLayers sets the default feature layer screen and minimap resolutions.
+func SetFeatureLayers(screen, minimap int) {
+	Set("featureScreen", fmt.Sprint(screen))
+	Set("featureMinimap", fmt.Sprint(minimap))
+}
+
+// SetRender sets the default rendered observation screen and minimap resolutions.
+func SetRender(screen, minimap int) {
+	Set("renderScreen", fmt.Sprint(screen))
+	Set("renderMinimap", fmt.Sprint(minimap))
+}
+
+// interfaceOptions returns the interface options with any feature layer or render settings
+// from the command line flags added (unless they were already set by SetInterfaceOptions).
+func interfaceOptions() *api.InterfaceOptions {
+	options := *processInterfaceOptions
+	if options.FeatureLayer == nil && featureScreen > 0 {
+		options.FeatureLayer = &api.SpatialCameraSetup{
+			Resolution:        &api.Size2DI{X: int32(featureScreen), Y: int32(featureScreen)},
+			MinimapResolution: &api.Size2DI{X: int32(featureMinimap), Y: int32(featureMinimap)},
+			Width:             float32(featureWidth),
+		}
+	}
+	if options.Render == nil && renderScreen > 0 {
+		options.Render = &api.SpatialCameraSetup{
+			Resolution:        &api.Size2DI{X: int32(renderScreen), Y: int32(renderScreen)},
+			MinimapResolution: &api.Size2DI{X: int32(renderMinimap), Y: int32(renderMinimap)},
+		}
+	}
+	return &options
+}
@@ -2,7 +2,7 @@ package runner
 
 import (
 	"flag"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 )
@@ -10,12 +10,30 @@ import (
 // Set changes the default value of a command line flag.
 func Set(name, value string) {
 	if err := flag.Set(name, value); err != nil {
-		log.Print(err)
+		slog.Warn("Unable to set flag", "name", name, "err", err)
 	}
 }
 
 var hasLoaded = false
 
+// LoadSettings applies the command line flags by setting up logging and checking for the
+// StarCraft II executable. RunAgent and RunMatch parse the flags and do this themselves, but
+// a program that calls flag.Parse first (e.g. for flags of its own) must call LoadSettings
+// after it.
+func LoadSettings() {
+	if hasLoaded {
+		return
+	}
+	setupLogging()
+
+	if !hasProcessPath() {
+		slog.Warn("Can't find executable path, hope that it's ok. If not, " +
+			"please run StarCraft II first or use the --executable <path> arg")
+	}
+
+	hasLoaded = true
+}
+
 func loadSettings() bool {
 	if flag.Parsed() {
 		return hasLoaded
@@ -28,13 +46,7 @@ func loadSettings() bool {
 		flag.PrintDefaults()
 		os.Exit(0)
 	}
-
-	if !hasProcessPath() {
-		log.Println("Can't find executable path, hope that it's ok. If not, " +
-			"please run StarCraft II first or use the --executable <path> arg")
-	}
-
-	hasLoaded = true
+	LoadSettings()
 	return true
 }
 
@@ -1,7 +1,11 @@
 package runner
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/chippydip/go-sc2ai/api"
 	"github.com/chippydip/go-sc2ai/client"
@@ -13,93 +17,92 @@ type gameConfig struct {
 	playerSetup []*api.PlayerSetup
 	ports       client.Ports
 
-	clients  []*client.Client
-	started  bool
-	lastPort int
+	clients   []*client.Client
+	players   []int // the playerSetup index of each client
+	started   bool
+	lastPort  int
+	portStart int
+	realtime  bool
+
+	maxGameLoop uint32        // stop the game at this loop (if non-zero)
+	timeout     time.Duration // stop the match after this long (if non-zero)
 }
 
 func newGameConfig(participants ...client.PlayerSetup) *gameConfig {
 	config := &gameConfig{
-		"127.0.0.1",
-		nil,
-		nil,
-		client.Ports{},
-		nil,
-		false,
-		0,
+		netAddress: launchAddress,
+		portStart:  launchPortStart,
+		realtime:   processRealtime,
 	}
 
 	for _, p := range participants {
 		if p.Agent != nil {
 			config.clients = append(config.clients, &client.Client{Agent: p.Agent})
+			config.players = append(config.players, len(config.playerSetup))
 		}
 		config.playerSetup = append(config.playerSetup, p.PlayerSetup)
 	}
 	return config
 }
 
-func (config *gameConfig) startGame(mapPath string) {
+func (config *gameConfig) startGame(mapPath string) error {
 	if !config.createGame(mapPath) {
-		log.Fatal("Failed to create game.")
+		return errors.New("failed to create game")
+	}
+	if err := config.joinGame(); err != nil {
+		return fmt.Errorf("unable to join game: %v", err)
 	}
-	config.joinGame()
+	return nil
 }
 
 func (config *gameConfig) createGame(mapPath string) bool {
 	if !config.started {
-		log.Panic("Game not started")
+		slog.Error("Game not started")
+		return false
 	}
 
 	// Create with the first client
-	err := config.clients[0].CreateGame(mapPath, config.playerSetup, processRealtime)
+	c := config.clients[0]
+	req, err := mapRequest(c, mapPath)
+	if err == nil {
+		req.PlayerSetup = config.playerSetup
+		req.Realtime = config.realtime
+		err = c.RequestCreateGame(req)
+	}
 	if err != nil {
-		log.Print(err)
+		c.Logger().Error("Failed to create game", "err", err)
 		return false
 	}
 	return true
 }
 
-func (config *gameConfig) joinGame() bool {
-	// TODO: Make this parallel and get rid of the WaitJoinGame method
-	for i, client := range config.clients {
-		if err := client.RequestJoinGame(config.playerSetup[i], processInterfaceOptions, config.ports); err != nil {
-			log.Fatalf("Unable to join game: %v", err)
+// joinGame joins all clients to the game at the same time (multiplayer joins only return
+// once every player has joined) and returns the first error.
+func (config *gameConfig) joinGame() error {
+	errs := make([]error, len(config.clients))
+
+	var wg sync.WaitGroup
+	for i, c := range config.clients {
+		if _, ok := c.Agent.(*BotProcess); ok {
+			continue // joins by itself
 		}
+		wg.Add(1)
+		go func(i int, c *client.Client) {
+			defer wg.Done()
+			errs[i] = c.RequestJoinGame(config.playerSetup[config.players[i]], interfaceOptions(), config.ports)
+		}(i, c)
 	}
+	wg.Wait()
 
-	// Check if any errors occurred during game start
-	// errors := false
-	// for _, client := range clients {
-	// 	errs := client.GetClientErrors()
-	// 	if len(errs) > 0 {
-	// 		client.Agent.OnError(errs, agent.Control().GetProtocolErrors())
-	// 		errors = true
-	// 	}
-
-	// 	//agent.Control().UseGeneralizedAbility(useGeneralizedAbilityID)
-	// }
-	// if errors {
-	// 	return false
-	// }
-
-	// Run all clients on game start
-	// for _, agent := range agents {
-	// 	agent.Control().GetObservation()
-	// }
-	// for _, agent := range agents {
-	// 	agent.OnGameFullStart()
-	// }
-	// for _, agent := range agents {
-	// 	agent.Control().OnGameStart()
-	// 	agent.OnGameStart()
-	// }
-	// for _, agent := range agents {
-	// 	agent.Control().IssueEvents(agent.Actions().Commands())
-	// }
-	return true
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (config *gameConfig) connect(port int) {
+func (config *gameConfig) connect(port int) error {
 	pi := client.ProcessInfo{Path: "", PID: 0, Port: port}
 
 	// Set process info for each bot
@@ -112,12 +115,13 @@ func (config *gameConfig) connect(port int) {
 		pi := config.processInfo[i]
 
 		if err := client.Connect(config.netAddress, pi.Port, processConnectTimeout); err != nil {
-			log.Panic("Failed to connect")
+			return fmt.Errorf("failed to connect: %v", err)
 		}
 	}
 
 	// Assume starcraft has started after succesfully attaching to a server
 	config.started = true
+	return nil
 }
 
 func (config *gameConfig) setupPorts(numAgents int, startPort int, checkSingle bool) {
@@ -1,7 +1,9 @@
 package runner
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -19,10 +21,18 @@ var (
 	launchPortStart        = 8168
 	launchExtraCommandArgs = []string(nil)
 	launchPortListen       = ""
+	launchAddress          = "127.0.0.1"
 )
 
 func init() {
 	flagStr("listen", &launchPortListen, "The port StarCraft II process listens for incoming connections")
+	flagStr("address", &launchAddress, "Address to connect to StarCraft II on, a new process is only launched if nothing is listening")
+}
+
+// SetAddress sets the default address to connect to StarCraft II on. Use it together with
+// SetMapSource to run games on an instance that doesn't share a file system with the bot.
+func SetAddress(address string) {
+	Set("address", address)
 }
 
 // SetGameVersion specifies a specific base game and data version to use when launching.
@@ -31,30 +41,39 @@ func SetGameVersion(baseBuild uint32, dataVersion string) {
 	launchDataVersion = dataVersion
 }
 
-func (config *gameConfig) reLaunchStarcraft() {
+func (config *gameConfig) reLaunchStarcraft() error {
 	config.killAll()
-	config.launchStarcraft()
+	return config.launchStarcraft()
 }
 
-func (config *gameConfig) launchStarcraft() {
+func (config *gameConfig) launchStarcraft() error {
 	if len(config.clients) == 0 {
-		log.Panic("No agents set")
+		return errors.New("runner: no agents set")
 	}
 
 	portStart := 0
 	if len(config.processInfo) != len(config.clients) {
 		config.killAll()
-		config.processInfo = config.launchProcesses(config.clients)
-		portStart = launchPortStart + len(config.processInfo) - 1
+		info, err := config.launchProcesses(config.clients)
+		config.processInfo = info
+		if err != nil {
+			config.killAll()
+			return err
+		}
+		portStart = config.portStart + len(config.processInfo) - 1
 	}
 
 	config.setupPorts(len(config.clients), portStart, true)
 	config.started = true
 	config.lastPort = portStart
+	return nil
 }
 
 func (config *gameConfig) killAll() {
 	for _, pi := range config.processInfo {
+		if pi.PID == 0 {
+			continue // not launched by us
+		}
 		if proc, err := os.FindProcess(pi.PID); err == nil && proc != nil {
 			proc.Kill()
 		}
@@ -62,17 +81,18 @@ func (config *gameConfig) killAll() {
 	config.processInfo = nil
 }
 
-func (config *gameConfig) launchProcesses(clients []*client.Client) []client.ProcessInfo {
+func (config *gameConfig) launchProcesses(clients []*client.Client) ([]client.ProcessInfo, error) {
 	// Make sure we have a valid executable path
 	path := processPathForBuild(launchBaseBuild)
 	if _, err := os.Stat(path); err != nil {
-		log.Print("Executable path can't be found, try running the StarCraft II executable first.")
+		slog.Warn("Executable path can't be found, try running the StarCraft II executable first.")
 		if len(path) > 0 {
-			log.Printf("%v does not exist on your filesystem.", path)
+			slog.Warn("Executable does not exist on your filesystem", "path", path)
 		}
 	}
 
 	info := make([]client.ProcessInfo, len(clients))
+	errs := make([]error, len(clients))
 
 	// Start an sc2 process for each bot
 	var wg sync.WaitGroup
@@ -81,26 +101,26 @@ func (config *gameConfig) launchProcesses(clients []*client.Client) []client.Pro
 		go func(i int, c *client.Client) {
 			defer wg.Done()
 
-			info[i] = config.launchAndAttach(path, c)
-
+			info[i], errs[i] = config.launchAndAttach(path, c, i)
 		}(i, c)
 	}
 	wg.Wait()
 
-	return info
+	return info, errors.Join(errs...)
 }
 
-func (config *gameConfig) launchAndAttach(path string, c *client.Client) client.ProcessInfo {
+func (config *gameConfig) launchAndAttach(path string, c *client.Client, i int) (client.ProcessInfo, error) {
 	pi := client.ProcessInfo{}
-	pi.Port = launchPortStart + len(config.processInfo) - 1
+	pi.Port = config.portStart + i - 1
 
 	// See if we can connect to an old instance real quick before launching
 	if err := c.TryConnect(config.netAddress, pi.Port); err != nil {
-		if len(launchPortListen) == 0 {
-			launchPortListen = config.netAddress
+		listen := launchPortListen
+		if len(listen) == 0 {
+			listen = config.netAddress
 		}
 		args := []string{
-			"-listen", launchPortListen,
+			"-listen", listen,
 			"-port", strconv.Itoa(pi.Port),
 			// DirectX will fail if multiple games try to launch in fullscreen mode. Force them into windowed mode.
 			"-displayMode", "0",
@@ -116,19 +136,19 @@ func (config *gameConfig) launchAndAttach(path string, c *client.Client) client.
 		pi.Path = path
 		pi.PID = startProcess(pi.Path, args)
 		if pi.PID == 0 {
-			log.Print("Unable to start sc2 executable with path: ", pi.Path)
+			c.Logger().Error("Unable to start sc2 executable", "path", pi.Path)
 		} else {
-			log.Printf("Launched SC2 (%v), PID: %v", pi.Path, pi.PID)
+			c.Logger().Info("Launched SC2", "path", pi.Path, "pid", pi.PID)
 		}
 
 		// Attach
 		if err := c.Connect(config.netAddress, pi.Port, processConnectTimeout); err != nil {
-			log.Panic("Failed to connect")
+			return pi, fmt.Errorf("runner: failed to connect to StarCraft II on port %v: %w", pi.Port, err)
 		}
 	}
 
 	c.SetProcessInfo(pi)
-	return pi
+	return pi, nil
 }
 
 func startProcess(path string, args []string) int {
@@ -147,7 +167,7 @@ func startProcess(path string, args []string) int {
 	}
 
 	if err := cmd.Start(); err != nil {
-		log.Print(err)
+		slog.Error("Failed to start process", "path", path, "err", err)
 		return 0
 	}
 
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+var (
+	logLevel  = ""
+	logFormat = ""
+)
+
+func init() {
+	flagStr("logLevel", &logLevel, "Minimum level to log (debug, info, warn or error)")
+	flagStr("logFormat", &logFormat, "Log output format (text or json), the standard log format is used by default")
+}
+
+// SetLogLevel sets the default minimum level to log.
+func SetLogLevel(level slog.Level) {
+	Set("logLevel", level.String())
+}
+
+// SetLogFormat sets the default log output format (text or json).
+func SetLogFormat(format string) {
+	Set("logFormat", format)
+}
+
+// setupLogging replaces the default logger based on the logging flags. Each client's logger
+// is derived from the default one unless the agent sets its own.
+func setupLogging() {
+	var level slog.Level
+	if len(logLevel) > 0 {
+		if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+			slog.Warn("Invalid log level", "level", logLevel, "err", err)
+		}
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(logFormat) {
+	case "":
+		slog.SetLogLoggerLevel(level)
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, options)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, options)))
+	default:
+		slog.Warn("Unknown log format", "format", logFormat)
+		slog.SetLogLoggerLevel(level)
+	}
+}
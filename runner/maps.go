@@ -1,18 +1,35 @@
 package runner
 
 import (
+	"fmt"
+	"log/slog"
 	"math/rand"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
 )
 
 var (
-	mapName = Random1v1Map()
+	mapName   = Random1v1Map()
+	mapSource = "path"
 )
 
+// mapMessageOverhead is extra room for the rest of a request that carries map data.
+const mapMessageOverhead = 4 * 1024
+
+var messageSizeMu sync.Mutex
+
 func init() {
 	flagStr("map", &mapName, "Which map to run.")
+	flagStr("mapSource", &mapSource, "How the game gets the map: path (a file the game can read), "+
+		"data (send the local file with the request), upload (save the local file on the game's machine first) "+
+		"or battlenet (download it by name)")
 }
 
 // SetMap sets the default map to use.
@@ -20,6 +37,87 @@ func SetMap(name string) {
 	Set("map", name)
 }
 
+// SetMapSource sets the default way of giving the map to the game: "path", "data", "upload"
+// or "battlenet". Anything other than "path" allows using a game that doesn't share a file
+// system with the bot (for example in a container or on another machine).
+func SetMapSource(source string) {
+	Set("mapSource", source)
+}
+
+// mapRequest returns a create game request for the map using the current map source. The
+// map is uploaded using c if needed.
+func mapRequest(c *client.Client, path string) (api.RequestCreateGame, error) {
+	switch mapSource {
+	case "path":
+		return api.RequestCreateGame{
+			Map: &api.RequestCreateGame_LocalMap{LocalMap: &api.LocalMap{MapPath: path}},
+		}, nil
+
+	case "data", "upload":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return api.RequestCreateGame{}, err
+		}
+		if size := len(data) + mapMessageOverhead; size > client.MaxMessageSize {
+			return api.RequestCreateGame{}, fmt.Errorf("map %v is too large to send (%v bytes, client.MaxMessageSize is %v), "+
+				"call runner.SizeMessagesForMaps before connecting or use a different map source", path, len(data), client.MaxMessageSize)
+		}
+
+		remotePath := filepath.Base(path)
+		if mapSource == "data" {
+			return api.RequestCreateGame{
+				Map: &api.RequestCreateGame_LocalMap{LocalMap: &api.LocalMap{MapPath: remotePath, MapData: data}},
+			}, nil
+		}
+
+		if err := c.RemoteSaveMap(data, remotePath); err != nil {
+			return api.RequestCreateGame{}, err
+		}
+		return api.RequestCreateGame{
+			Map: &api.RequestCreateGame_LocalMap{LocalMap: &api.LocalMap{MapPath: remotePath}},
+		}, nil
+
+	case "battlenet":
+		return api.RequestCreateGame{
+			Map: &api.RequestCreateGame_BattlenetMapName{
+				BattlenetMapName: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+			},
+		}, nil
+
+	default:
+		return api.RequestCreateGame{}, fmt.Errorf("unknown map source: %v", mapSource)
+	}
+}
+
+// SizeMessagesForMaps raises client.MaxMessageSize (if needed) so the maps can be sent to the
+// game with the data or upload map source. The limit is fixed when connecting, so this must
+// be called before then. RunAgent and RunMatch do this for their own map, but when playing
+// several matches at the same time call it for all their maps first.
+func SizeMessagesForMaps(names ...string) error {
+	matchSettings.Do(func() {
+		loadSettings()
+	})
+	if mapSource != "data" && mapSource != "upload" {
+		return nil
+	}
+
+	messageSizeMu.Lock()
+	defer messageSizeMu.Unlock()
+
+	for _, name := range names {
+		path := mapPathFor(name)
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if size := int(info.Size()) + mapMessageOverhead; size > client.MaxMessageSize {
+			slog.Info("Raising the message size limit for map", "path", path, "size", size)
+			client.MaxMessageSize = size
+		}
+	}
+	return nil
+}
+
 // Random1v1Map returns a random map name from the current 1v1 ladder map pool.
 func Random1v1Map() string {
 	currentMaps := maps2021season1
@@ -29,11 +127,22 @@ func Random1v1Map() string {
 }
 
 func mapPath() string {
+	return mapPathFor(mapName)
+}
+
+func mapPathFor(name string) string {
+	// A local file is preferred when the map is sent to the game from here
+	if mapSource == "data" || mapSource == "upload" {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+
 	// Fix linux client using maps directory instead of Maps
 	if runtime.GOOS != "windows" {
-		return filepath.Join(defaultSc2Path(), "Maps", mapName)
+		return filepath.Join(defaultSc2Path(), "Maps", name)
 	}
-	return mapName
+	return name
 }
 
 // TODO: check for current ladder pool maps, download if missing?
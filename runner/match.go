@@ -0,0 +1,273 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+var matchSettings sync.Once
+
+// errMatchOver is returned from Step once the match has been stopped.
+var errMatchOver = errors.New("runner: match is over")
+
+// MatchOptions configures a game between two agents.
+type MatchOptions struct {
+	// Map is the map name or path, the -map flag is used if empty.
+	Map string
+	// Realtime runs the game in realtime mode.
+	Realtime bool
+	// PortStart is the first port used for the two game instances and the game itself
+	// (about ten ports are used). Matches running at the same time need separate ranges,
+	// zero uses the default.
+	PortStart int
+	// Replay is the path to save a replay of the game to (if set).
+	Replay string
+	// Kill stops the game instances after the match instead of leaving them running for the
+	// next match with the same ports to reuse.
+	Kill bool
+	// MaxGameLoop stops the game once it reaches this game loop (if non-zero) and leaves the
+	// results Undecided. It is checked by the agents running in this process, the first one
+	// to reach it stops the match (killing bot programs and disconnecting any other agent),
+	// so a game between two bot programs is only stopped by the Timeout.
+	MaxGameLoop uint32
+	// Timeout stops the match if it takes longer than this (if non-zero). Bot programs are
+	// killed, agents running here are disconnected and RunMatch returns an error.
+	Timeout time.Duration
+}
+
+// MatchResult is the outcome of a game between two agents.
+type MatchResult struct {
+	Results  [2]api.Result // each player's result, Undecided if the game didn't finish
+	Crashed  [2]bool       // whether each agent panicked or couldn't be started
+	GameLoop uint32        // the game loop the game ended on
+	Duration time.Duration // how long the game took to play
+	Replay   string        // the path the replay was saved to, if any
+}
+
+// RunMatch launches a game instance for each agent and plays p1 against p2, returning once
+// the game is over. Either player may also be a computer (but not both) or a BotProcess.
+// Matches with separate port ranges can be run at the same time.
+func RunMatch(p1, p2 client.PlayerSetup, opts MatchOptions) (result MatchResult, err error) {
+	matchSettings.Do(func() {
+		loadSettings()
+	})
+	if !hasLoaded {
+		return result, errors.New("runner: the flags were parsed without calling LoadSettings")
+	}
+
+	if p1.Agent == nil && p2.Agent == nil {
+		return result, errors.New("runner: RunMatch needs an agent for at least one player")
+	}
+
+	config := newGameConfig(p1, p2)
+	config.realtime = opts.Realtime
+	config.maxGameLoop = opts.MaxGameLoop
+	config.timeout = opts.Timeout
+	if opts.PortStart > 0 {
+		config.portStart = opts.PortStart
+	}
+	defer config.recordTapes(matchTapePath())()
+	defer config.collectMetrics()()
+
+	if opts.Kill {
+		defer config.killAll()
+	}
+
+	name := mapName
+	if len(opts.Map) > 0 {
+		name = opts.Map
+	}
+	if err := SizeMessagesForMaps(name); err != nil {
+		return result, err
+	}
+	if err := config.launchStarcraft(); err != nil {
+		return result, err
+	}
+
+	return config.playMatch(mapPathFor(name), opts.Replay)
+}
+
+// playMatch creates a game for the connected clients, runs all agents until it ends and then
+// leaves the game.
+func (config *gameConfig) playMatch(mapPath, replayPath string) (result MatchResult, err error) {
+	if !config.createGame(mapPath) {
+		return result, fmt.Errorf("runner: failed to create game on %v", mapPath)
+	}
+
+	// Bot programs connect to their game instance and join by themselves
+	procs := make([]*botRun, len(config.clients))
+	for i, c := range config.clients {
+		if b, ok := c.Agent.(*BotProcess); ok {
+			c.Close()
+			opponent := config.playerSetup[1-config.players[i]].GetPlayerName()
+			if procs[i], err = b.start(config, config.processInfo[i].Port, opponent); err != nil {
+				killAll(procs)
+				return result, err
+			}
+		}
+	}
+	if err := config.joinGame(); err != nil {
+		killAll(procs)
+		return result, err
+	}
+
+	// Stopping the match kills the bot programs and disconnects every agent running here except
+	// keep (if any), otherwise agents still waiting on a step would block forever
+	var stopped, timedOut atomic.Bool
+	var connected atomic.Int32 // the agent still connected after stopping
+	var stopOnce sync.Once
+	stop := func(keep int) {
+		stopOnce.Do(func() {
+			connected.Store(int32(keep))
+			stopped.Store(true)
+			killProcs(procs)
+			for i, c := range config.clients {
+				if procs[i] == nil && i != keep {
+					c.Close()
+				}
+			}
+		})
+	}
+
+	// Stop everything if the match takes too long
+	if config.timeout > 0 {
+		timer := time.AfterFunc(config.timeout, func() {
+			timedOut.Store(true)
+			stop(-1)
+		})
+		defer timer.Stop()
+	}
+
+	start := time.Now()
+	results := make([]botResult, len(config.clients))
+	var wg sync.WaitGroup
+	for i, c := range config.clients {
+		wg.Add(1)
+		go func(i int, c *client.Client) {
+			defer wg.Done()
+			if procs[i] != nil {
+				results[i] = procs[i].wait()
+				return
+			}
+			info := &matchInfo{c, config.maxGameLoop, &stopped}
+			c.SetStepCheck(info.check)
+			results[i].Crashed = runAgent(c, info)
+
+			// Bot programs and the other agents don't know this one stopped at the game loop limit
+			if c.IsInGame() {
+				stop(i)
+			}
+		}(i, c)
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+
+	// The game instances are still in the middle of the game
+	if stopped.Load() {
+		defer config.killAll()
+	}
+
+	// Save the replay and collect the results of the agents running here (there is no
+	// connection left to save a replay with after a timeout, and only the agent that stopped
+	// the match is still connected otherwise)
+	saved := timedOut.Load()
+	for i, c := range config.clients {
+		if procs[i] != nil {
+			continue
+		}
+		if len(replayPath) > 0 && !saved && (!stopped.Load() || i == int(connected.Load())) {
+			if err := c.SaveReplay(replayPath); err != nil {
+				c.Logger().Error("Failed to save replay", "path", replayPath, "err", err)
+			} else {
+				result.Replay = replayPath
+			}
+			saved = true
+		}
+
+		results[i].Result = api.Result_Undecided.String()
+		results[i].GameLoop = c.Observation().GetObservation().GetGameLoop()
+		for _, r := range c.Observation().GetPlayerResult() {
+			if r.GetPlayerId() == c.PlayerID() {
+				results[i].Result = r.GetResult().String()
+			}
+		}
+		cleanup(c)
+	}
+
+	for i, r := range results {
+		player := config.players[i]
+		result.Results[player] = api.Result(api.Result_value[r.Result])
+		result.Crashed[player] = r.Crashed
+		if r.GameLoop > result.GameLoop {
+			result.GameLoop = r.GameLoop
+		}
+	}
+	if len(results) == 1 {
+		// The computer gets the opposite result
+		computer := 1 - config.players[0]
+		switch result.Results[config.players[0]] {
+		case api.Result_Victory:
+			result.Results[computer] = api.Result_Defeat
+		case api.Result_Defeat:
+			result.Results[computer] = api.Result_Victory
+		default:
+			result.Results[computer] = result.Results[config.players[0]]
+		}
+	}
+	if timedOut.Load() {
+		return result, fmt.Errorf("runner: match timed out after %v", config.timeout)
+	}
+	return result, nil
+}
+
+// killAll stops any bot programs that were started and waits for them to exit.
+func killAll(procs []*botRun) {
+	for _, p := range procs {
+		if p != nil {
+			p.kill()
+			p.wait()
+		}
+	}
+}
+
+// killProcs stops any bot programs that were started without waiting for them.
+func killProcs(procs []*botRun) {
+	for _, p := range procs {
+		if p != nil {
+			p.kill()
+		}
+	}
+}
+
+// matchInfo stops the agent once the game reaches the match's game loop limit or the match
+// is stopped.
+type matchInfo struct {
+	*client.Client
+	maxGameLoop uint32
+	stopped     *atomic.Bool
+}
+
+func (info *matchInfo) over() bool {
+	if info.stopped.Load() {
+		return true
+	}
+	return info.maxGameLoop > 0 && info.Observation().GetObservation().GetGameLoop() >= info.maxGameLoop
+}
+
+func (info *matchInfo) IsInGame() bool {
+	return !info.over() && info.Client.IsInGame()
+}
+
+// check is the client's step check, so every way of stepping stops at the limit.
+func (info *matchInfo) check() error {
+	if info.over() {
+		return errMatchOver
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+	"github.com/chippydip/go-sc2ai/sc2test"
+)
+
+// TestMain runs the test binary as the bot program started by the BotProcess tests when
+// RUNNER_TEST_BOT is set.
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("RUNNER_TEST_BOT"); len(mode) > 0 {
+		os.Exit(runBotHelper(mode))
+	}
+	os.Exit(m.Run())
+}
+
+// runBotHelper checks the ladder flags and then does whatever mode says.
+func runBotHelper(mode string) int {
+	flags := map[string]string{}
+	for i := 1; i+1 < len(os.Args); i++ {
+		if strings.HasPrefix(os.Args[i], "--") {
+			flags[os.Args[i]] = os.Args[i+1]
+		}
+	}
+	if flags["--GamePort"] != "5000" || flags["--StartPort"] != "6000" || flags["--OpponentId"] != "opponent" {
+		return 3
+	}
+
+	switch {
+	case strings.HasPrefix(mode, "result:"):
+		os.WriteFile(flags["--resultFile"], []byte(strings.TrimPrefix(mode, "result:")), 0644)
+	case mode == "crash":
+		return 1
+	case mode == "hang":
+		time.Sleep(time.Minute)
+	}
+	return 0
+}
+
+func startBotHelper(t *testing.T, mode string) *botRun {
+	t.Helper()
+	t.Setenv("RUNNER_TEST_BOT", mode)
+
+	b := &BotProcess{Command: []string{os.Args[0]}}
+	config := &gameConfig{netAddress: "127.0.0.1", lastPort: 6000}
+	r, err := b.start(config, 5000, "opponent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestBotProcess(t *testing.T) {
+	if _, err := (&BotProcess{}).start(&gameConfig{}, 5000, "opponent"); err == nil {
+		t.Error("expected an error starting a BotProcess without a command")
+	}
+
+	tests := []struct {
+		mode string
+		kill bool
+		want botResult
+	}{
+		{`result:{"result": "Victory", "game_loop": 1234}`, false, botResult{"Victory", 1234, false}},
+		{`result:{"result": "Defeat", "game_loop": 99, "crashed": true}`, false, botResult{"Defeat", 99, true}},
+		{`result:not json`, false, botResult{"Undecided", 0, true}},
+		{"exit", false, botResult{"Undecided", 0, true}}, // no result file
+		{"crash", false, botResult{"Undecided", 0, true}},
+		{"hang", true, botResult{"Undecided", 0, false}}, // killed by the runner
+	}
+	for _, test := range tests {
+		r := startBotHelper(t, test.mode)
+		if test.kill {
+			r.kill()
+		}
+		if got := r.wait(); got != test.want {
+			t.Errorf("%v: result = %+v, want %+v", test.mode, got, test.want)
+		}
+		if _, err := os.Stat(r.dir); !os.IsNotExist(err) {
+			t.Errorf("%v: result directory was not removed", test.mode)
+		}
+	}
+}
+
+func TestPlayMatchComputer(t *testing.T) {
+	agent := client.AgentFunc(func(info client.AgentInfo) {
+		for info.IsInGame() {
+			if err := info.Step(8); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	})
+	bot := client.NewParticipant(api.Race_Terran, agent, "bot")
+	computer := client.NewComputer(api.Race_Zerg, api.Difficulty_Easy, api.AIBuild_RandomBuild)
+
+	for _, computerFirst := range []bool{false, true} {
+		// The agent wins, the computer gets the opposite result
+		s := &sc2test.Server{}
+		s.Handler = func(r *api.Request) *api.Response {
+			if r.GetObservation() != nil && s.GameLoop() >= 16 && s.Status() == api.Status_in_game {
+				s.EndGame(&api.PlayerResult{PlayerId: 1, Result: api.Result_Victory})
+			}
+			return nil
+		}
+		s.Start()
+		defer s.Close()
+
+		config := newGameConfig(bot, computer)
+		want := [2]api.Result{api.Result_Victory, api.Result_Defeat}
+		if computerFirst {
+			config = newGameConfig(computer, bot)
+			want[0], want[1] = want[1], want[0]
+		}
+		if err := config.connect(s.Port()); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := config.playMatch("Test.SC2Map", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Results != want || result.Crashed != [2]bool{} {
+			t.Errorf("computer first = %v: results = %v, crashed = %v, want %v", computerFirst, result.Results, result.Crashed, want)
+		}
+	}
+}
+
+func TestPlayMatchLimits(t *testing.T) {
+	agent := client.AgentFunc(func(info client.AgentInfo) {
+		for info.IsInGame() {
+			info.Step(8)
+		}
+	})
+	bot := client.NewParticipant(api.Race_Terran, agent, "bot")
+	computer := client.NewComputer(api.Race_Zerg, api.Difficulty_Easy, api.AIBuild_RandomBuild)
+
+	// The game is stopped at the game loop limit without a result
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	config := newGameConfig(bot, computer)
+	config.maxGameLoop = 50
+	if err := config.connect(s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	result, err := config.playMatch("Test.SC2Map", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.GameLoop < 50 || result.GameLoop >= 50+crashStepSize || result.Results != [2]api.Result{api.Result_Undecided, api.Result_Undecided} || result.Crashed != [2]bool{} {
+		t.Errorf("result = %+v, want stopped at loop 50", result)
+	}
+
+	// A game that stops responding times out
+	release := make(chan struct{})
+	s = &sc2test.Server{}
+	s.Handler = func(r *api.Request) *api.Response {
+		if r.GetStep() != nil && s.GameLoop() >= 16 {
+			<-release
+		}
+		return nil
+	}
+	s.Start()
+	defer s.Close()
+	defer close(release)
+
+	config = newGameConfig(bot, computer)
+	config.timeout = 100 * time.Millisecond
+	if err := config.connect(s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.playMatch("Test.SC2Map", ""); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err = %v, want a timeout", err)
+	}
+}
+
+// lockstep makes two servers step together like a multiplayer game, a step only finishes
+// once the other player has asked to step at least as far.
+type lockstep struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	targets [2]uint32
+	closed  bool
+}
+
+func (l *lockstep) step(i int, target uint32) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.targets[i] = target
+	l.cond.Broadcast()
+	for l.targets[1-i] < target && !l.closed {
+		l.cond.Wait()
+	}
+}
+
+func (l *lockstep) close() {
+	l.mu.Lock()
+	l.closed = true
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+func TestPlayMatchLimitsTwoAgents(t *testing.T) {
+	l := &lockstep{}
+	l.cond = sync.NewCond(&l.mu)
+	defer l.close()
+
+	var joined sync.WaitGroup
+	joined.Add(2)
+	servers := make([]*sc2test.Server, 2)
+	for i := range servers {
+		i, s := i, &sc2test.Server{}
+		s.Handler = func(r *api.Request) *api.Response {
+			switch {
+			case r.GetJoinGame() != nil:
+				joined.Done()
+				joined.Wait()
+			case r.GetStep() != nil:
+				l.step(i, s.GameLoop()+r.GetStep().GetCount())
+			}
+			return nil
+		}
+		s.Start()
+		defer s.Close()
+		servers[i] = s
+	}
+
+	// The agents step by different amounts so one reaches the limit first while the other
+	// is still waiting for it to step
+	agent := func(stepSize int) client.Agent {
+		return client.AgentFunc(func(info client.AgentInfo) {
+			for info.IsInGame() {
+				info.Step(stepSize)
+			}
+		})
+	}
+	config := newGameConfig(client.NewParticipant(api.Race_Terran, agent(8), "p1"),
+		client.NewParticipant(api.Race_Zerg, agent(5), "p2"))
+	config.maxGameLoop = 50
+	for i, c := range config.clients {
+		if err := c.TryConnect(servers[i].Address(), servers[i].Port()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config.started = true
+
+	done := make(chan MatchResult)
+	go func() {
+		result, err := config.playMatch("Test.SC2Map", "")
+		if err != nil {
+			t.Error(err)
+		}
+		done <- result
+	}()
+	select {
+	case result := <-done:
+		if result.GameLoop < 50 || result.Results != [2]api.Result{api.Result_Undecided, api.Result_Undecided} || result.Crashed != [2]bool{} {
+			t.Errorf("result = %+v, want stopped at loop 50", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("match did not stop at the game loop limit")
+	}
+}
+
+func TestMatchTapePath(t *testing.T) {
+	defer func(path string) { tapePath = path }(tapePath)
+
+	tapePath = ""
+	if path := matchTapePath(); len(path) != 0 {
+		t.Errorf("tape path = %q, want none", path)
+	}
+
+	tapePath = filepath.Join("tapes", "game.tape")
+	first, second := matchTapePath(), matchTapePath()
+	if first == second || filepath.Ext(first) != ".tape" || !strings.HasPrefix(first, filepath.Join("tapes", "game.match")) {
+		t.Errorf("tape paths = %q, %q, want a separate one for each match", first, second)
+	}
+}
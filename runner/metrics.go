@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"expvar"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+var (
+	metricsEnabled = false
+	metricsAddr    = ""
+
+	metricsOnce sync.Once
+	metricsVars *expvar.Map
+)
+
+func init() {
+	flagBool("metrics", &metricsEnabled, "Collect request metrics and log a summary at the end of each game")
+	flagStr("metricsAddr", &metricsAddr, "Serve metrics with expvar at http://<addr>/debug/vars (implies -metrics)")
+}
+
+// SetMetrics enables metrics collection and the end of game summary by default.
+func SetMetrics() {
+	Set("metrics", "true")
+}
+
+// SetMetricsAddr sets the default address to serve metrics on.
+func SetMetricsAddr(addr string) {
+	Set("metricsAddr", addr)
+}
+
+// collectMetrics attaches a metrics collector to each client (if enabled) and returns a func
+// that logs a summary of them.
+func (config *gameConfig) collectMetrics() func() {
+	if !metricsEnabled && len(metricsAddr) == 0 {
+		return func() {}
+	}
+
+	metricsOnce.Do(func() {
+		metricsVars = expvar.NewMap("sc2")
+		if len(metricsAddr) > 0 {
+			slog.Info("Serving metrics", "url", fmt.Sprintf("http://%v/debug/vars", metricsAddr))
+			go func() {
+				slog.Error("Metrics server stopped", "err", http.ListenAndServe(metricsAddr, nil))
+			}()
+		}
+	})
+
+	metrics := make([]*client.Metrics, len(config.clients))
+	for i, c := range config.clients {
+		metrics[i] = &client.Metrics{}
+		c.SetMetrics(metrics[i])
+		metricsVars.Set(fmt.Sprint(i+1), metrics[i])
+	}
+
+	return func() {
+		for i, c := range config.clients {
+			c.Logger().Info("Metrics summary:\n" + metrics[i].Summary())
+		}
+	}
+}
@@ -3,7 +3,7 @@ package runner
 import (
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"os/user"
@@ -21,11 +21,9 @@ var (
 	processInterfaceOptions = &api.InterfaceOptions{
 		Raw:   true,
 		Score: true,
-		// FeatureLayer: &api.SpatialCameraSetup{
-		// 	Resolution: &api.Size2DI{X: 512, Y: 512},
-		// },
 	}
 	processRealtime          = false
+	processSimRealtime       = false
 	processConnectTimeout, _ = time.ParseDuration("2m")
 )
 
@@ -34,6 +32,7 @@ func init() {
 	flagStr("executable", &processPath, "The path to StarCraft II.")
 	//flagInt("port", &processSettings.portStart, "The port to make StarCraft II listen on.")
 	flagBool("realtime", &processRealtime, "Whether to run StarCraft II in real time or not.")
+	flagBool("simRealtime", &processSimRealtime, "Run in step mode but skip the game loops that would pass in real time while the bot is working.")
 	flagDur("timeout", &processConnectTimeout, "Timeout for how long the library will block for a response.")
 }
 
@@ -47,6 +46,11 @@ func SetRealtime() {
 	Set("realtime", "1")
 }
 
+// SetSimulatedRealtime sets the default simulated realtime option to enabled.
+func SetSimulatedRealtime() {
+	Set("simRealtime", "1")
+}
+
 // SetConnectTimeout sets how long to wait for a connection to the game.
 func SetConnectTimeout(timeout time.Duration) {
 	Set("timeout", fmt.Sprint(timeout))
@@ -62,24 +66,24 @@ func defaultExecutable() string {
 
 	// Default to the environment variable (Linux mostly)
 	if sc2path := os.Getenv("SC2PATH"); len(sc2path) > 0 {
-		log.Printf("SC2PATH: %v", sc2path)
+		slog.Info("Using SC2PATH", "path", sc2path)
 		path = filepath.Join(sc2path, "Versions", "dummy")
 	}
 
 	// Read value from ExecuteInfo.txt if the current user has run the game before
 	file, err := getUserDirectory()
 	if err != nil {
-		log.Printf("Error getting user directory: %v", err)
+		slog.Warn("Error getting user directory", "err", err)
 	} else if len(file) > 0 {
 		file = filepath.Join(file, "Starcraft II", "ExecuteInfo.txt")
-		log.Printf("ExecuteInfo path: %v", file)
+		slog.Info("Reading ExecuteInfo", "path", file)
 	}
 
 	if props, err := newPropertyReader(file); err == nil {
 		props.getString("executable", &path)
-		log.Printf("  executable = %v", path)
+		slog.Info("Found executable", "path", path)
 	} else {
-		log.Printf("Error reading `executable`: %v", err)
+		slog.Warn("Error reading `executable`", "err", err)
 	}
 
 	// Backout the defaulted path to the Versions directory and then find the latest Base game
@@ -112,14 +116,14 @@ func processPathForBuild(build uint32) string {
 		_, exe := filepath.Split(path)
 		root := sc2Path(path)
 		if root == "" {
-			log.Printf("Can't find game dir: %v", path)
+			slog.Warn("Can't find game dir", "path", path)
 		}
 		dir := filepath.Join(sc2Path(path), "Versions")
 
 		// Get the path of the correct version and make sure the exe exists
 		path = filepath.Join(dir, fmt.Sprintf("Base%v", build), exe)
 		if _, err := os.Stat(path); err != nil {
-			log.Printf("Base version not found: %v", err)
+			slog.Warn("Base version not found", "err", err)
 		}
 	}
 	return path
@@ -134,7 +138,7 @@ func getUserDirectory() (string, error) {
 
 		sout := strings.TrimSpace(string(out))
 		if err != nil {
-			log.Print("Documents directory lookup failed: ", sout)
+			slog.Warn("Documents directory lookup failed", "output", sout)
 			return "", err
 		}
 
@@ -146,7 +150,7 @@ func getUserDirectory() (string, error) {
 	case "darwin":
 		user, err := user.Current()
 		if err != nil {
-			log.Print("Failed to get current user:", err)
+			slog.Warn("Failed to get current user", "err", err)
 			return "", err
 		}
 		return filepath.Join(user.HomeDir, "Library", "Application Support", "Blizzard"), nil
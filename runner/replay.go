@@ -2,7 +2,8 @@ package runner
 
 import (
 	"io/ioutil"
-	"log"
+	"log/slog"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -21,7 +22,7 @@ var (
 func SetReplayPath(path string) error {
 	replayFiles = nil
 	if p, err := filepath.Abs(path); err != nil {
-		log.Printf("Failed to get absolute path: %v", err)
+		slog.Warn("Failed to get absolute path", "err", err)
 	} else {
 		path = p
 	}
@@ -92,44 +93,50 @@ func startReplay(config *gameConfig, path string) bool {
 	// Get info about the replay
 	info, err := config.clients[0].RequestReplayInfo(path)
 	if err != nil {
-		log.Printf("Unable to get replay info: %v", err)
+		slog.Error("Unable to get replay info", "path", path, "err", err)
 		return false
 	}
 
 	// Allow the bot user to skip certain replays after looking at the info
 	if replayFilter != nil && !replayFilter(info) {
-		log.Printf("Skipping replay: %v", path)
+		slog.Info("Skipping replay", "path", path)
 		return false
 	}
 
 	// Check if we need to re-launch the game
 	current := config.clients[0].Proto()
 	if info.GetBaseBuild() != current.GetBaseBuild() || info.GetDataVersion() != current.GetDataVersion() {
-		log.Printf("Version mis-match, relaunching client")
+		slog.Info("Version mis-match, relaunching client")
 		SetGameVersion(info.GetBaseBuild(), info.GetDataVersion())
 
-		config.reLaunchStarcraft()
+		if err := config.reLaunchStarcraft(); err != nil {
+			slog.Error("Unable to relaunch StarCraft II", "err", err)
+			os.Exit(1)
+		}
 
 		current = config.clients[0].Proto()
 		if info.GetBaseBuild() != current.GetBaseBuild() {
-			log.Fatalf("Failed to launch correct base build: %v %v", current.GetBaseBuild(), info.GetBaseBuild())
+			slog.Error("Failed to launch correct base build", "got", current.GetBaseBuild(), "want", info.GetBaseBuild())
+			os.Exit(1)
 		}
 		if info.GetDataVersion() != current.GetDataVersion() {
-			log.Fatalf("Failed to launch correct data version: %v %v", current.GetDataVersion(), info.GetDataVersion())
+			slog.Error("Failed to launch correct data version", "got", current.GetDataVersion(), "want", info.GetDataVersion())
+			os.Exit(1)
 		}
 	}
 
-	log.Printf("Launching replay: %v", path)
+	slog.Info("Launching replay", "path", path)
 	err = config.clients[0].RequestStartReplay(api.RequestStartReplay{
 		Replay: &api.RequestStartReplay_ReplayPath{
 			ReplayPath: path,
 		},
 		ObservedPlayerId: replayObservedPlayer,
-		Options:          processInterfaceOptions,
+		Options:          interfaceOptions(),
 		Realtime:         processRealtime,
 	})
 	if err != nil {
-		log.Fatalf("Unable to start replay: %v", err)
+		slog.Error("Unable to start replay", "path", path, "err", err)
+		return false
 	}
 
 	return true
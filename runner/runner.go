@@ -1,7 +1,8 @@
 package runner
 
 import (
-	"log"
+	"log/slog"
+	"os"
 	"sync"
 
 	"github.com/chippydip/go-sc2ai/client"
@@ -38,27 +39,44 @@ func RunAgent(agent client.PlayerSetup) {
 
 	var numAgents = 1
 	var config *gameConfig
-	if computerOpponent && ladderGamePort == 0 {
+	if (computerOpponent || len(scenarioPath) > 0) && ladderGamePort == 0 {
 		config = newGameConfig(agent, client.NewComputer(computerRace, computerDifficulty, computerBuild))
 	} else {
 		numAgents = 2
 		config = newGameConfig(agent)
 	}
+	defer config.recordTapes(tapePath)()
+	defer config.collectMetrics()()
 
 	if ladderGamePort > 0 {
-		log.Print("Connecting to port ", ladderGamePort)
-		config.connect(ladderGamePort)
+		slog.Info("Connecting to ladder game", "port", ladderGamePort)
+		if err := config.connect(ladderGamePort); err != nil {
+			slog.Error("Unable to connect to ladder game", "err", err)
+			os.Exit(1)
+		}
 		config.setupPorts(numAgents, ladderStartPort, false)
-		config.joinGame()
-		log.Print(" Successfully joined game")
+		if err := config.joinGame(); err != nil {
+			slog.Error("Unable to join game", "err", err)
+			os.Exit(1)
+		}
+		slog.Info("Successfully joined game")
 	} else {
-		config.launchStarcraft()
+		if err := SizeMessagesForMaps(mapName); err != nil {
+			slog.Warn("Unable to check the map size", "err", err)
+		}
+		if err := config.launchStarcraft(); err != nil {
+			slog.Error("Unable to launch StarCraft II", "err", err)
+			os.Exit(1)
+		}
 
-		if runReplays(config) {
+		if runReplays(config) || runScenarios(config, agent.Agent) {
 			return // skip actual game
 		}
 
-		config.startGame(mapPath())
+		if err := config.startGame(mapPath()); err != nil {
+			slog.Error("Unable to start game", "err", err)
+			os.Exit(1)
+		}
 	}
 
 	run(config.clients)
@@ -72,24 +90,32 @@ func run(clients []*client.Client) {
 		go func(client *client.Client) {
 			defer wg.Done()
 
-			runAgent(client)
+			crashed := runAgent(client, client)
 			cleanup(client)
+			writeResultFile(client, crashed)
 		}(c)
 	}
 
 	wg.Wait()
 }
 
-func runAgent(c *client.Client) {
+// crashStepSize is how many game loops to step at a time to keep the game running after the
+// agent stopped (10 seconds).
+const crashStepSize = 224
+
+// runAgent runs the client's agent with info (usually the client itself) and returns true if
+// it panicked or couldn't be started.
+func runAgent(c *client.Client, info client.AgentInfo) (crashed bool) {
 	defer func() {
 		if p := recover(); p != nil {
-			client.ReportPanic(p)
+			c.ReportPanic(p)
+			crashed = true
 		}
 
 		// If the bot crashed before losing, keep the game running (force the opponent to earn the win)
-		for c.IsInGame() {
-			if err := c.Step(224); err != nil { // 10 seconds per update
-				log.Print(err)
+		for info.IsInGame() {
+			if err := info.Step(crashStepSize); err != nil {
+				c.Logger().Error("Failed to step", "err", err)
 				break
 			}
 		}
@@ -97,18 +123,21 @@ func runAgent(c *client.Client) {
 
 	// get GameInfo, Data, and Observation
 	if err := c.Init(); err != nil {
-		log.Printf("Failed to init client: %v", err)
-		return
+		c.Logger().Error("Failed to init client", "err", err)
+		return true
 	}
 
 	// make sure the bot was added to a game or replay
 	if !c.IsInGame() {
-		log.Print("Client is not in-game")
-		return
+		c.Logger().Error("Client is not in-game")
+		return true
 	}
 
 	// run the agent's code
-	c.Agent.RunAgent(c)
+	c.SetStepBudget(stepBudget())
+	c.SetSimulatedRealtime(processSimRealtime)
+	c.Agent.RunAgent(info)
+	return false
 }
 
 func cleanup(c *client.Client) {
@@ -120,7 +149,7 @@ func cleanup(c *client.Client) {
 	// Print the winner
 	for _, player := range c.Observation().GetPlayerResult() {
 		if player.GetPlayerId() == c.PlayerID() {
-			log.Print(player.GetResult())
+			c.Logger().Info("Game over", "result", player.GetResult())
 		}
 	}
 }
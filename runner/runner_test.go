@@ -0,0 +1,273 @@
+package runner
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+	"github.com/chippydip/go-sc2ai/sc2test"
+)
+
+func TestRunLadderGame(t *testing.T) {
+	s := &sc2test.Server{EndLoop: 100}
+	s.Start()
+	defer s.Close()
+
+	steps := 0
+	agent := client.AgentFunc(func(info client.AgentInfo) {
+		for info.IsInGame() {
+			if err := info.Step(8); err != nil {
+				t.Error(err)
+				return
+			}
+			steps++
+		}
+	})
+
+	config := newGameConfig(client.NewParticipant(api.Race_Terran, agent, "test"))
+	if err := config.connect(s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.joinGame(); err != nil {
+		t.Fatal(err)
+	}
+	run(config.clients)
+
+	if steps != 13 {
+		t.Errorf("steps = %v, want 13", steps)
+	}
+	if s.GameLoop() != 100 {
+		t.Errorf("GameLoop = %v, want 100", s.GameLoop())
+	}
+}
+
+func TestMapUpload(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	path := filepath.Join(t.TempDir(), "Test.SC2Map")
+	if err := os.WriteFile(path, []byte("map data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(source string) { mapSource = source }(mapSource)
+	mapSource = "upload"
+
+	agent := client.AgentFunc(func(client.AgentInfo) {})
+	config := newGameConfig(client.NewParticipant(api.Race_Terran, agent, "test"), client.NewComputer(api.Race_Zerg, api.Difficulty_Easy, api.AIBuild_RandomBuild))
+	if err := config.connect(s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	if !config.createGame(path) {
+		t.Fatal("createGame failed")
+	}
+
+	if string(s.SavedMap("Test.SC2Map")) != "map data" {
+		t.Errorf("map was not uploaded")
+	}
+	requests := s.Requests()
+	create := requests[len(requests)-1].GetCreateGame()
+	if create.GetLocalMap().GetMapPath() != "Test.SC2Map" || len(create.GetPlayerSetup()) != 2 {
+		t.Errorf("CreateGame = %v", create)
+	}
+}
+
+func TestMapTooLarge(t *testing.T) {
+	s := sc2test.NewServer()
+	defer s.Close()
+
+	data := bytes.Repeat([]byte("map data"), 2*1024)
+	path := filepath.Join(t.TempDir(), "Large.SC2Map")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(source string, size int) { mapSource, client.MaxMessageSize = source, size }(mapSource, client.MaxMessageSize)
+	mapSource = "upload"
+	client.MaxMessageSize = 4 * 1024
+
+	agent := client.AgentFunc(func(client.AgentInfo) {})
+	players := []client.PlayerSetup{
+		client.NewParticipant(api.Race_Terran, agent, "test"),
+		client.NewComputer(api.Race_Zerg, api.Difficulty_Easy, api.AIBuild_RandomBuild),
+	}
+
+	// Without raising the limit first the request fails before anything is sent
+	config := newGameConfig(players...)
+	if err := config.connect(s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mapRequest(config.clients[0], mapPathFor(path)); err == nil || !strings.Contains(err.Error(), "SizeMessagesForMaps") {
+		t.Fatalf("err = %v, want a map too large error", err)
+	}
+
+	// Raising it before connecting allows the upload
+	if err := SizeMessagesForMaps(path); err != nil {
+		t.Fatal(err)
+	}
+	if client.MaxMessageSize <= len(data) {
+		t.Fatalf("MaxMessageSize = %v, map is %v bytes", client.MaxMessageSize, len(data))
+	}
+	config = newGameConfig(players...)
+	if err := config.connect(s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	if !config.createGame(mapPathFor(path)) {
+		t.Fatal("createGame failed")
+	}
+	if !bytes.Equal(s.SavedMap("Large.SC2Map"), data) {
+		t.Errorf("map was not uploaded")
+	}
+}
+
+func TestPlayMatch(t *testing.T) {
+	// Multiplayer joins only return once every player has joined
+	var joined sync.WaitGroup
+	joined.Add(2)
+	servers := make([]*sc2test.Server, 2)
+	for i := range servers {
+		s := &sc2test.Server{EndLoop: 100}
+		s.Handler = func(r *api.Request) *api.Response {
+			if r.GetJoinGame() != nil {
+				joined.Done()
+				joined.Wait()
+			}
+			return nil
+		}
+		s.Start()
+		defer s.Close()
+		servers[i] = s
+	}
+
+	p1 := client.AgentFunc(func(info client.AgentInfo) {
+		for info.IsInGame() {
+			if err := info.Step(8); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	})
+	p2 := client.AgentFunc(func(info client.AgentInfo) {
+		panic("crash")
+	})
+
+	config := newGameConfig(client.NewParticipant(api.Race_Terran, p1, "p1"), client.NewParticipant(api.Race_Zerg, p2, "p2"))
+	for i, c := range config.clients {
+		if err := c.TryConnect(servers[i].Address(), servers[i].Port()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config.started = true
+
+	result, err := config.playMatch("Test.SC2Map", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Results != [2]api.Result{api.Result_Tie, api.Result_Tie} {
+		t.Errorf("Results = %v", result.Results)
+	}
+	if result.Crashed != [2]bool{false, true} {
+		t.Errorf("Crashed = %v", result.Crashed)
+	}
+	if result.GameLoop != 100 {
+		t.Errorf("GameLoop = %v, want 100", result.GameLoop)
+	}
+}
+
+func TestRunMatchLaunchError(t *testing.T) {
+	// Find a port nothing is listening on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	defer func(timeout time.Duration) { processConnectTimeout = timeout }(processConnectTimeout)
+	processConnectTimeout = 100 * time.Millisecond
+
+	agent := client.AgentFunc(func(info client.AgentInfo) {})
+	runMatch := func() error {
+		_, err := RunMatch(client.NewParticipant(api.Race_Terran, agent, "p1"),
+			client.NewComputer(api.Race_Zerg, api.Difficulty_Easy, api.AIBuild_RandomBuild),
+			MatchOptions{PortStart: port + 1})
+		return err
+	}
+
+	// The test flags are already parsed, so the settings must be loaded explicitly
+	defer func(loaded bool) { hasLoaded = loaded }(hasLoaded)
+	hasLoaded = false
+	if err := runMatch(); err == nil || !strings.Contains(err.Error(), "LoadSettings") {
+		t.Errorf("err = %v, want a settings error", err)
+	}
+	LoadSettings()
+	if err := runMatch(); err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Errorf("err = %v, want a connection error", err)
+	}
+}
+
+func TestRecordAndRunTape(t *testing.T) {
+	s := &sc2test.Server{EndLoop: 100}
+	s.Start()
+	defer s.Close()
+
+	steps := 0
+	agent := client.AgentFunc(func(info client.AgentInfo) {
+		for info.IsInGame() {
+			if err := info.Step(8); err != nil {
+				t.Error(err)
+				return
+			}
+			steps++
+		}
+	})
+
+	defer func(path string) { tapePath = path }(tapePath)
+	tapePath = filepath.Join(t.TempDir(), "game.tape")
+
+	config := newGameConfig(client.NewParticipant(api.Race_Terran, agent, "test"))
+	stop := config.recordTapes(tapePath)
+	if err := config.connect(s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.joinGame(); err != nil {
+		t.Fatal(err)
+	}
+	run(config.clients)
+	stop()
+
+	// Playing the tape back runs the agent through the same game without the server
+	s.Close()
+	recorded := steps
+	steps = 0
+	if err := RunTape(tapePath, agent); err != nil {
+		t.Fatal(err)
+	}
+	if recorded != 13 || steps != recorded {
+		t.Errorf("steps = %v, recorded %v, want 13", steps, recorded)
+	}
+}
+
+func TestStepBudgetFlags(t *testing.T) {
+	defer func(perStep time.Duration, window int, limit, refill time.Duration) {
+		budgetPerStep, budgetWindow, budgetWindowLimit, budgetBankRefill = perStep, window, limit, refill
+	}(budgetPerStep, budgetWindow, budgetWindowLimit, budgetBankRefill)
+
+	budgetPerStep, budgetWindow, budgetWindowLimit, budgetBankRefill = 0, 10, time.Second, time.Millisecond
+	budget := stepBudget()
+	if budget.Window != 10 || budget.WindowLimit != time.Second || budget.BankRefill != time.Millisecond {
+		t.Errorf("budget = %+v", budget)
+	}
+
+	budgetWindowLimit = 0
+	if budget := stepBudget(); budget != (client.StepBudget{}) {
+		t.Errorf("budget = %+v, want disabled", budget)
+	}
+}
@@ -0,0 +1,543 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+	"github.com/chippydip/go-sc2ai/enums/protoss"
+	"github.com/chippydip/go-sc2ai/enums/terran"
+	"github.com/chippydip/go-sc2ai/enums/zerg"
+	"github.com/chippydip/go-sc2ai/scenario"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	scenarioPath    = ""
+	scenarioReport  = ""
+	scenarioAgents  = map[string]client.Agent{}
+	scenarioResults = []ScenarioResult(nil)
+)
+
+func init() {
+	flagStr("scenarios", &scenarioPath, "Run the micro test scenarios in this JSON or YAML file (or directory of files) instead of a normal game")
+	flagStr("scenarioReport", &scenarioReport, "Write the scenario pass/fail report to this JSON file")
+}
+
+// SetScenarioPath sets a scenario file or directory of scenario files to run instead of a
+// normal game (see ScenarioFile).
+func SetScenarioPath(path string) {
+	Set("scenarios", path)
+}
+
+// SetScenarioReport sets the default file to write the scenario report to.
+func SetScenarioReport(path string) {
+	Set("scenarioReport", path)
+}
+
+// RegisterAgent makes an agent available to scenario files by name.
+func RegisterAgent(name string, agent client.Agent) {
+	scenarioAgents[name] = agent
+}
+
+// ScenarioResults returns the results of the scenarios run by RunAgent, e.g. to set the
+// process exit code.
+func ScenarioResults() []ScenarioResult {
+	return scenarioResults
+}
+
+// ScenarioFile describes a micro test. All scenarios are run one after another on a single
+// game which is restarted in between, units are set up with debug commands and the agent
+// plays until the loop budget runs out or one player has no units left. For example:
+//
+//	{
+//		"name": "marines beat zerglings",
+//		"clear": true,
+//		"units": [
+//			{"player": 1, "unit": "Marine", "count": 8, "pos": {"x": 40, "y": 40}},
+//			{"player": 2, "unit": "Zergling", "count": 6, "pos": {"x": 46, "y": 40}}
+//		],
+//		"toggle": ["show_map"],
+//		"loops": 1344,
+//		"assert": [
+//			{"check": "wins", "player": 1},
+//			{"check": "alive", "player": 1, "unit": "Marine", "min": 4},
+//			{"check": "dead", "player": 1, "unit": "worker", "max": 0}
+//		]
+//	}
+//
+// Units are only tracked while they are visible, so enemy units that fight out of vision
+// need "show_map". "clear" leaves structures alive so a melee game doesn't end, and the
+// structures it leaves are ignored when deciding whether the scenario is over and by the
+// assertions.
+//
+// Files ending in .yaml or .yml are read as YAML with the same field names.
+type ScenarioFile struct {
+	Name   string           `json:"name"`
+	Map    string           `json:"map"`    // defaults to the -map flag
+	Agent  string           `json:"agent"`  // a name passed to RegisterAgent, defaults to the RunAgent agent
+	Clear  bool             `json:"clear"`  // kill all non-neutral units except structures first
+	Units  []ScenarioUnits  `json:"units"`  // units to spawn
+	Toggle []string         `json:"toggle"` // debug game states to toggle, e.g. "fast_build"
+	Loops  uint32           `json:"loops"`  // game loop budget, defaults to one minute
+	Assert []ScenarioAssert `json:"assert"`
+}
+
+// ScenarioUnits is a group of units to spawn. Unit is the name from the game data (e.g.
+// "Marine"). Energy, Life and Shields are only set if non-zero.
+type ScenarioUnits struct {
+	Player  api.PlayerID `json:"player"`
+	Unit    string       `json:"unit"`
+	Count   int          `json:"count"`
+	Pos     api.Point2D  `json:"pos"`
+	Energy  float32      `json:"energy"`
+	Life    float32      `json:"life"`
+	Shields float32      `json:"shields"`
+}
+
+// ScenarioAssert is checked at the end of a scenario. Check is one of:
+//
+//	wins   the player has units left and no other player does
+//	alive  the number of the player's units still alive is within [min, max]
+//	dead   the number of the player's units that died is within [min, max]
+//	loops  the number of game loops the scenario took is within [min, max]
+//
+// Unit limits the units considered to a type name from the game data, "worker" or
+// "structure". A zero player means all players.
+type ScenarioAssert struct {
+	Check  string       `json:"check"`
+	Player api.PlayerID `json:"player"`
+	Unit   string       `json:"unit"`
+	Min    *int         `json:"min"`
+	Max    *int         `json:"max"`
+}
+
+// ScenarioResult is the outcome of a single scenario.
+type ScenarioResult struct {
+	File     string   `json:"file"`
+	Name     string   `json:"name"`
+	Passed   bool     `json:"passed"`
+	Loops    uint32   `json:"loops"`
+	Failures []string `json:"failures,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// errScenarioOver is returned from Step once the scenario is over.
+var errScenarioOver = errors.New("scenario is over")
+
+func runScenarios(config *gameConfig, agent client.Agent) bool {
+	if len(scenarioPath) == 0 {
+		return false
+	}
+
+	files, err := scenarioFiles(scenarioPath)
+	if err != nil {
+		slog.Error("Unable to find scenarios", "path", scenarioPath, "err", err)
+		return true
+	}
+
+	current := ""
+	passed := 0
+	for _, file := range files {
+		result := config.runScenario(file, agent, &current)
+		if result.Passed {
+			passed++
+			slog.Info("Scenario passed", "name", result.Name, "loops", result.Loops)
+		} else {
+			slog.Error("Scenario failed", "name", result.Name, "loops", result.Loops, "failures", result.Failures, "err", result.Error)
+		}
+		scenarioResults = append(scenarioResults, result)
+	}
+	slog.Info("Scenarios finished", "passed", passed, "failed", len(files)-passed)
+
+	if len(scenarioReport) > 0 {
+		if err := writeScenarioReport(scenarioReport, scenarioResults); err != nil {
+			slog.Error("Unable to write scenario report", "path", scenarioReport, "err", err)
+		}
+	}
+
+	if len(current) > 0 {
+		config.clients[0].RequestLeaveGame()
+	}
+	return true
+}
+
+// scenarioFiles returns path if it is a file or all scenario files in it if it is a directory.
+func scenarioFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".json", ".yaml", ".yml":
+			if !file.IsDir() {
+				paths = append(paths, filepath.Join(path, file.Name()))
+			}
+		}
+	}
+	return paths, nil
+}
+
+func loadScenario(path string) (*ScenarioFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is converted to JSON so both use the json field tags. This needs YAML 1.2 (v3),
+	// older versions read a "y" key as true.
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+	}
+
+	s := &ScenarioFile{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	if len(s.Name) == 0 {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if s.Loops == 0 {
+		s.Loops = 1344
+	}
+	return s, nil
+}
+
+func writeScenarioReport(path string, results []ScenarioResult) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// runScenario runs a single scenario file. current is the map of the game that is already
+// running (if any), which is restarted instead of creating a new game.
+func (config *gameConfig) runScenario(path string, agent client.Agent, current *string) (result ScenarioResult) {
+	result.File = path
+	result.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	s, err := loadScenario(path)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	result.Name = s.Name
+
+	if len(s.Agent) > 0 {
+		if agent = scenarioAgents[s.Agent]; agent == nil {
+			result.Error = fmt.Sprintf("unknown agent %q", s.Agent)
+			return
+		}
+	}
+
+	c := config.clients[0]
+	if err := config.restartScenarioGame(s, current); err != nil {
+		result.Error = err.Error()
+		return
+	}
+	c.ClearCallbacks()
+
+	// Set up the units, anything left after clearing the map is ignored
+	sc := scenario.New(c)
+	ignore := map[api.UnitTag]bool{}
+	if s.Clear {
+		sc.ClearUnits()
+		for _, u := range c.Observation().GetObservation().GetRawData().GetUnits() {
+			ignore[u.Tag] = true
+		}
+	}
+	for _, u := range s.Units {
+		unitType, ok := unitTypeByName(c.Data(), u.Unit)
+		if !ok {
+			result.Error = fmt.Sprintf("unknown unit %q", u.Unit)
+			return
+		}
+		sc.Spawn(u.Player, unitType, u.Count, u.Pos)
+		if u.Energy != 0 {
+			sc.Energy(u.Energy)
+		}
+		if u.Life != 0 {
+			sc.Life(u.Life)
+		}
+		if u.Shields != 0 {
+			sc.Shields(u.Shields)
+		}
+	}
+	for _, name := range s.Toggle {
+		state, ok := api.DebugGameState_value[name]
+		if !ok {
+			result.Error = fmt.Sprintf("unknown game state %q", name)
+			return
+		}
+		sc.Toggle(api.DebugGameState(state))
+	}
+	_, err = sc.Run()
+	if !c.IsInGame() {
+		err = errors.New("game ended during setup (a melee map ends when a player has no structures left)")
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	// Run the agent until the scenario is over
+	t := newScenarioTracker(c, s.Loops, ignore)
+	c.OnObservation(t.update)
+	c.SetStepCheck(func() error {
+		if t.over() {
+			return errScenarioOver
+		}
+		return nil
+	})
+	defer c.SetStepCheck(nil)
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				c.ReportPanic(p)
+				result.Error = fmt.Sprintf("agent panicked: %v", p)
+			}
+		}()
+
+		c.SetStepBudget(stepBudget())
+		c.SetSimulatedRealtime(processSimRealtime)
+		agent.RunAgent(&scenarioInfo{c, t})
+	}()
+
+	// Finish the scenario if the agent returned early
+	for c.IsInGame() && !t.over() {
+		stepSize := 8
+		if remaining := int(t.end - t.loop()); remaining < stepSize {
+			stepSize = remaining
+		}
+		if err := c.Step(stepSize); err != nil {
+			result.Error = err.Error()
+			break
+		}
+	}
+
+	result.Loops = t.loop() - t.start
+	for _, a := range s.Assert {
+		if msg := t.check(a); len(msg) > 0 {
+			result.Failures = append(result.Failures, msg)
+		}
+	}
+	result.Passed = len(result.Error) == 0 && len(result.Failures) == 0
+	return
+}
+
+// restartScenarioGame restarts the current game if it is on the right map or starts a new one.
+func (config *gameConfig) restartScenarioGame(s *ScenarioFile, current *string) error {
+	c := config.clients[0]
+	path := mapPath()
+	if len(s.Map) > 0 {
+		path = mapPathFor(s.Map)
+	}
+
+	if *current == path {
+		err := c.RestartGame()
+		if err == nil {
+			return nil
+		}
+		c.Logger().Warn("Unable to restart game, creating a new one", "err", err)
+	}
+	if len(*current) > 0 {
+		c.RequestLeaveGame()
+	}
+
+	*current = ""
+	if !config.createGame(path) {
+		return fmt.Errorf("failed to create game on %v", path)
+	}
+	if err := config.joinGame(); err != nil {
+		return err
+	}
+	if err := c.Init(); err != nil {
+		return err
+	}
+	*current = path
+	return nil
+}
+
+// unitTypeByName finds an available unit type by its name in the game data.
+func unitTypeByName(data *api.ResponseData, name string) (api.UnitTypeID, bool) {
+	for _, u := range data.GetUnits() {
+		if u != nil && u.Available && strings.EqualFold(u.Name, name) {
+			return u.UnitId, true
+		}
+	}
+	return 0, false
+}
+
+// scenarioInfo reports the game as over once the scenario is over, stepping further is
+// stopped by the step check set in runScenario.
+type scenarioInfo struct {
+	*client.Client
+	t *scenarioTracker
+}
+
+func (info *scenarioInfo) IsInGame() bool {
+	return !info.t.over() && info.Client.IsInGame()
+}
+
+// scenarioTracker follows the units seen during a scenario to detect when it is over and
+// to check the assertions.
+type scenarioTracker struct {
+	info       client.AgentInfo
+	start, end uint32
+	ignore     map[api.UnitTag]bool
+	known      map[api.UnitTag]*api.Unit
+	dead       map[api.UnitTag]*api.Unit
+	players    map[api.PlayerID]bool
+}
+
+func newScenarioTracker(info client.AgentInfo, loops uint32, ignore map[api.UnitTag]bool) *scenarioTracker {
+	t := &scenarioTracker{
+		info:    info,
+		ignore:  ignore,
+		known:   map[api.UnitTag]*api.Unit{},
+		dead:    map[api.UnitTag]*api.Unit{},
+		players: map[api.PlayerID]bool{},
+	}
+	t.start = t.loop()
+	t.end = t.start + loops
+	t.update()
+	return t
+}
+
+func (t *scenarioTracker) loop() uint32 {
+	return t.info.Observation().GetObservation().GetGameLoop()
+}
+
+func (t *scenarioTracker) update() {
+	raw := t.info.Observation().GetObservation().GetRawData()
+	for _, u := range raw.GetUnits() {
+		if u.DisplayType == api.DisplayType_Visible && u.Alliance != api.Alliance_Neutral && !t.ignore[u.Tag] {
+			t.known[u.Tag] = u
+			t.players[u.Owner] = true
+		}
+	}
+	for _, tag := range raw.GetEvent().GetDeadUnits() {
+		if u, ok := t.known[tag]; ok {
+			delete(t.known, tag)
+			t.dead[tag] = u
+		}
+	}
+}
+
+// over returns true once the loop budget is used up or a player has no units left.
+func (t *scenarioTracker) over() bool {
+	if t.loop() >= t.end {
+		return true
+	}
+	for player := range t.players {
+		if t.count(t.known, player, "") == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns the number of units owned by player (or anyone if zero) of the given kind.
+func (t *scenarioTracker) count(units map[api.UnitTag]*api.Unit, player api.PlayerID, kind string) int {
+	n := 0
+	for _, u := range units {
+		if (player == 0 || u.Owner == player) && t.isKind(u, kind) {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *scenarioTracker) isKind(u *api.Unit, kind string) bool {
+	switch strings.ToLower(kind) {
+	case "":
+		return true
+	case "worker":
+		switch u.UnitType {
+		case terran.SCV, terran.MULE, protoss.Probe, zerg.Drone, zerg.DroneBurrowed:
+			return true
+		}
+		return false
+	}
+
+	units := t.info.Data().GetUnits()
+	if int(u.UnitType) >= len(units) || units[u.UnitType] == nil {
+		return false
+	}
+	data := units[u.UnitType]
+	if strings.EqualFold(kind, "structure") {
+		for _, attr := range data.Attributes {
+			if attr == api.Attribute_Structure {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.EqualFold(data.Name, kind)
+}
+
+// check returns a description of the failure if the assertion doesn't hold.
+func (t *scenarioTracker) check(a ScenarioAssert) string {
+	desc := fmt.Sprintf("%v player=%v", a.Check, a.Player)
+	if len(a.Unit) > 0 {
+		desc += " unit=" + a.Unit
+	}
+
+	var n int
+	switch strings.ToLower(a.Check) {
+	case "wins":
+		if t.count(t.known, a.Player, a.Unit) == 0 {
+			return desc + ": no units left"
+		}
+		for player := range t.players {
+			if player != a.Player && t.count(t.known, player, a.Unit) > 0 {
+				return fmt.Sprintf("%v: player %v has %v units left", desc, player, t.count(t.known, player, a.Unit))
+			}
+		}
+		return ""
+	case "alive":
+		n = t.count(t.known, a.Player, a.Unit)
+	case "dead":
+		n = t.count(t.dead, a.Player, a.Unit)
+	case "loops":
+		desc = a.Check
+		n = int(t.loop() - t.start)
+	default:
+		return fmt.Sprintf("unknown check %q", a.Check)
+	}
+
+	if a.Min != nil && n < *a.Min {
+		return fmt.Sprintf("%v: got %v, want at least %v", desc, n, *a.Min)
+	}
+	if a.Max != nil && n > *a.Max {
+		return fmt.Sprintf("%v: got %v, want at most %v", desc, n, *a.Max)
+	}
+	return ""
+}
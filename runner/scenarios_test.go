@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+	"github.com/chippydip/go-sc2ai/enums/terran"
+	"github.com/chippydip/go-sc2ai/enums/zerg"
+	"github.com/chippydip/go-sc2ai/sc2test"
+)
+
+// scenarioGame is a fake melee game where debug units appear and die on the next step and
+// a player with no structures left loses.
+type scenarioGame struct {
+	s       *sc2test.Server
+	units   []*api.Unit
+	dead    []api.UnitTag
+	dying   []api.UnitTag
+	nextTag api.UnitTag
+}
+
+func (g *scenarioGame) restart() {
+	g.units, g.dead, g.dying = nil, nil, nil
+	g.create(&api.DebugCreateUnit{UnitType: terran.CommandCenter, Owner: 1, Pos: &api.Point2D{X: 5, Y: 5}, Quantity: 1})
+	g.create(&api.DebugCreateUnit{UnitType: zerg.Hatchery, Owner: 2, Pos: &api.Point2D{X: 60, Y: 60}, Quantity: 1})
+}
+
+func (g *scenarioGame) create(create *api.DebugCreateUnit) {
+	for i := uint32(0); i < create.Quantity; i++ {
+		g.nextTag++
+		pos := create.Pos.ToPoint()
+		alliance := api.Alliance_Self
+		if create.Owner != 1 {
+			alliance = api.Alliance_Enemy
+		}
+		g.units = append(g.units, &api.Unit{Tag: g.nextTag, UnitType: create.UnitType, Owner: create.Owner,
+			Alliance: alliance, Pos: &pos, DisplayType: api.DisplayType_Visible})
+	}
+}
+
+func (g *scenarioGame) handle(r *api.Request) *api.Response {
+	switch {
+	case r.GetRestartGame() != nil:
+		g.restart()
+	case r.GetStep() != nil:
+		g.dead, g.dying = g.dying, nil
+	}
+
+	for _, cmd := range r.GetDebug().GetDebug() {
+		if create := cmd.GetCreateUnit(); create != nil {
+			g.create(create)
+		}
+		for _, tag := range cmd.GetKillUnit().GetTag() {
+			for i, u := range g.units {
+				if u.Tag == tag {
+					g.units = append(g.units[:i], g.units[i+1:]...)
+					g.dying = append(g.dying, tag)
+					break
+				}
+			}
+		}
+	}
+
+	if r.GetDebug() != nil {
+		for _, player := range []api.PlayerID{1, 2} {
+			if !g.hasStructure(player) {
+				g.s.EndGame(&api.PlayerResult{PlayerId: player, Result: api.Result_Defeat})
+			}
+		}
+	}
+	return nil // use the default behavior
+}
+
+func (g *scenarioGame) hasStructure(player api.PlayerID) bool {
+	for _, u := range g.units {
+		if u.Owner == player && (u.UnitType == terran.CommandCenter || u.UnitType == zerg.Hatchery) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRunScenarios(t *testing.T) {
+	s := &sc2test.Server{}
+	g := &scenarioGame{s: s}
+	g.restart()
+	s.Handler = g.handle
+	s.Data = func(*api.RequestData) *api.ResponseData {
+		units := make([]*api.UnitTypeData, zerg.Zergling+1)
+		for i := range units {
+			units[i] = &api.UnitTypeData{UnitId: api.UnitTypeID(i)}
+		}
+		units[terran.Marine] = &api.UnitTypeData{UnitId: terran.Marine, Name: "Marine", Available: true}
+		units[zerg.Zergling] = &api.UnitTypeData{UnitId: zerg.Zergling, Name: "Zergling", Available: true}
+		units[terran.CommandCenter] = &api.UnitTypeData{UnitId: terran.CommandCenter, Name: "CommandCenter",
+			Available: true, Attributes: []api.Attribute{api.Attribute_Structure}}
+		units[zerg.Hatchery] = &api.UnitTypeData{UnitId: zerg.Hatchery, Name: "Hatchery",
+			Available: true, Attributes: []api.Attribute{api.Attribute_Structure}}
+		return &api.ResponseData{Units: units}
+	}
+	s.Observation = func(*api.RequestObservation) *api.ResponseObservation {
+		return &api.ResponseObservation{Observation: &api.Observation{
+			GameLoop:     s.GameLoop(),
+			PlayerCommon: &api.PlayerCommon{PlayerId: 1},
+			RawData: &api.ObservationRaw{
+				Player: &api.PlayerRaw{},
+				Units:  g.units,
+				Event:  &api.Event{DeadUnits: g.dead},
+			},
+		}}
+	}
+	s.Start()
+	defer s.Close()
+
+	dir := t.TempDir()
+	setup := `"clear": true, "units": [
+		{"player": 1, "unit": "Marine", "count": 8, "pos": {"x": 20, "y": 20}},
+		{"player": 2, "unit": "zergling", "count": 6, "pos": {"x": 25, "y": 20}}
+	], "loops": 40, "assert": [
+		{"check": "wins", "player": 1},
+		{"check": "alive", "player": 1, "unit": "Marine", "min": 8},
+		{"check": "dead", "player": 2, "max": 6},
+		{"check": "loops", "max": 20}
+	]`
+	setupYAML := `clear: true
+units:
+  - {player: 1, unit: Marine, count: 8, pos: {x: 20, y: 20}}
+  - {player: 2, unit: zergling, count: 6, pos: {x: 25, y: 20}}
+loops: 40
+assert:
+  - check: wins
+    player: 1
+  - check: alive
+    player: 1
+    unit: Marine
+    min: 8
+  - {check: dead, player: 2, max: 6}
+  - {check: loops, max: 20}
+`
+	files := map[string]string{
+		"a_win.json":  `{"name": "win", ` + setup + `}`,
+		"b_idle.yaml": "name: idle\nagent: idle\n" + setupYAML,
+		"c_bad.yml":   "name: bad\nunits:\n  - player: 1\n    unit: Nope\n    count: 1\n",
+		"notes.txt":   "not a scenario",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func(path, report string) { scenarioPath, scenarioReport = path, report }(scenarioPath, scenarioReport)
+	scenarioPath = dir
+	scenarioReport = filepath.Join(dir, "report.out")
+	defer func() { scenarioResults = nil }()
+
+	// The default agent kills all enemy zerglings
+	agent := client.AgentFunc(func(info client.AgentInfo) {
+		for info.IsInGame() {
+			var enemies []api.UnitTag
+			for _, u := range info.Observation().GetObservation().GetRawData().GetUnits() {
+				if u.Owner == 2 && u.UnitType == zerg.Zergling {
+					enemies = append(enemies, u.Tag)
+				}
+			}
+			if len(enemies) > 0 {
+				info.SendDebugCommands([]*api.DebugCommand{{
+					Command: &api.DebugCommand_KillUnit{KillUnit: &api.DebugKillUnit{Tag: enemies}},
+				}})
+			}
+			if err := info.Step(1); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	})
+	RegisterAgent("idle", client.AgentFunc(func(info client.AgentInfo) {}))
+
+	config := newGameConfig(client.NewParticipant(api.Race_Terran, agent, "test"),
+		client.NewComputer(api.Race_Zerg, api.Difficulty_Easy, api.AIBuild_RandomBuild))
+	if err := config.connect(s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	if !runScenarios(config, agent) {
+		t.Fatal("scenarios not run")
+	}
+
+	results := ScenarioResults()
+	if len(results) != 3 {
+		t.Fatalf("results = %v", results)
+	}
+	if r := results[0]; !r.Passed || r.Loops > 20 {
+		t.Errorf("win = %+v", r)
+	}
+	if r := results[1]; r.Passed || r.Loops != 40 || len(r.Failures) != 2 {
+		t.Errorf("idle = %+v", r) // doesn't win and takes too long
+	}
+	if r := results[2]; r.Passed || r.Error != `unknown unit "Nope"` {
+		t.Errorf("bad = %+v", r)
+	}
+	if _, err := os.Stat(scenarioReport); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+var (
+	tapePath = ""
+)
+
+func init() {
+	flagStr("tape", &tapePath, "Record all game requests and responses to this file for later playback")
+}
+
+// SetTapePath sets the default file to record game requests and responses to.
+func SetTapePath(path string) {
+	Set("tape", path)
+}
+
+// matchCount numbers the matches played by this process.
+var matchCount atomic.Int32
+
+// matchTapePath returns a separate tape path (if enabled) for each match so matches played at
+// the same time don't overwrite each other's tapes.
+func matchTapePath() string {
+	if len(tapePath) == 0 {
+		return ""
+	}
+	return numberedPath(tapePath, "match"+strconv.Itoa(int(matchCount.Add(1))))
+}
+
+// numberedPath inserts suffix before the extension of path.
+func numberedPath(path string, suffix string) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%v.%v%v", strings.TrimSuffix(path, ext), suffix, ext)
+}
+
+// recordTapes starts recording for each client to path (if set) and returns a func to stop.
+func (config *gameConfig) recordTapes(path string) func() {
+	if len(path) == 0 {
+		return func() {}
+	}
+
+	var files []*os.File
+	for i, c := range config.clients {
+		path := path
+		if len(config.clients) > 1 {
+			path = numberedPath(path, strconv.Itoa(i+1))
+		}
+
+		file, err := os.Create(path)
+		if err != nil {
+			c.Logger().Error("Unable to record tape", "err", err)
+			continue
+		}
+		c.Logger().Info("Recording tape", "path", path)
+		c.RecordTape(file)
+		files = append(files, file)
+	}
+
+	return func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+}
+
+// RunTape runs the agent against a tape recorded with the -tape flag instead of a live
+// game. The agent must make the same sequence of requests it did when it was recorded.
+func RunTape(path string, agent client.Agent) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	c := &client.Client{Agent: agent}
+	if err := c.ConnectTape(file); err != nil {
+		return err
+	}
+	c.Logger().Info("Playing tape", "path", path)
+
+	runAgent(c, c)
+	return nil
+}
@@ -0,0 +1,48 @@
+package sc2test
+
+import (
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+// Connect returns a new client connected to the server or fails the test.
+func Connect(t testing.TB, s *Server) *client.Client {
+	t.Helper()
+
+	c := &client.Client{}
+	if err := c.TryConnect(s.Address(), s.Port()); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// StartGame creates and joins a game as a Terran participant with the raw interface against
+// a Zerg computer and then initializes the client, or fails the test.
+func StartGame(t testing.TB, c *client.Client) {
+	t.Helper()
+
+	players := []*api.PlayerSetup{
+		{Type: api.PlayerType_Participant, Race: api.Race_Terran},
+		{Type: api.PlayerType_Computer, Race: api.Race_Zerg},
+	}
+	if err := c.CreateGame("test.SC2Map", players, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RequestJoinGame(players[0], &api.InterfaceOptions{Raw: true}, client.Ports{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Init(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// NewGame connects a new client to the server and starts a game with StartGame.
+func NewGame(t testing.TB, s *Server) *client.Client {
+	t.Helper()
+
+	c := Connect(t, s)
+	StartGame(t, c)
+	return c
+}
@@ -0,0 +1,467 @@
+// Package sc2test provides a fake StarCraft II game server for testing code that talks to
+// the game without needing the game installed.
+package sc2test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gorilla/websocket"
+)
+
+// Server is a fake game that speaks the SC2 API protocol over a websocket at /sc2api.
+//
+// By default it behaves like a minimal game: it can be created and joined, stepping
+// advances the game loop, and the game ends once GameLoop reaches EndLoop (if non-zero).
+// QuickSave, QuickLoad and RestartGame save and restore the game loop, DebugEndGame ends the
+// game, and SaveMap stores the map data so it can be checked with SavedMap.
+// Any of the handlers may be set to script different behavior. Handlers are called one
+// at a time and may use the Server methods to inspect or change the game state.
+type Server struct {
+	// BaseBuild and DataVersion are reported in ping responses.
+	BaseBuild   uint32
+	DataVersion string
+
+	// EndLoop is the game loop at which the game ends (zero means never).
+	EndLoop uint32
+
+	// Handler is called for every request before the typed handlers below. If it returns
+	// a non-nil response that response is sent instead (its Id and Status will be filled
+	// in if not set).
+	Handler func(*api.Request) *api.Response
+
+	Ping        func(*api.RequestPing) *api.ResponsePing
+	CreateGame  func(*api.RequestCreateGame) *api.ResponseCreateGame
+	JoinGame    func(*api.RequestJoinGame) *api.ResponseJoinGame
+	GameInfo    func(*api.RequestGameInfo) *api.ResponseGameInfo
+	Data        func(*api.RequestData) *api.ResponseData
+	Observation func(*api.RequestObservation) *api.ResponseObservation
+	Step        func(*api.RequestStep) *api.ResponseStep
+	Query       func(*api.RequestQuery) *api.ResponseQuery
+	Action      func(*api.RequestAction) *api.ResponseAction
+	Debug       func(*api.RequestDebug) *api.ResponseDebug
+	LeaveGame   func(*api.RequestLeaveGame) *api.ResponseLeaveGame
+
+	server   *httptest.Server
+	handleMu sync.Mutex
+
+	mu       sync.Mutex
+	status   api.Status
+	gameLoop uint32
+	saved    uint32
+	maps     map[string][]byte
+	players  []*api.PlayerSetup
+	results  []*api.PlayerResult
+	requests []*api.Request
+	conns    []*websocket.Conn
+}
+
+// NewServer creates and starts a server with the default behavior.
+func NewServer() *Server {
+	s := &Server{}
+	s.Start()
+	return s
+}
+
+// Start begins listening on a local port. Handlers must be set before calling Start.
+func (s *Server) Start() {
+	s.status = api.Status_launched
+
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sc2api", func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, ws)
+		s.mu.Unlock()
+
+		s.serve(ws)
+	})
+	s.server = httptest.NewServer(mux)
+}
+
+// Close disconnects any clients and stops the server.
+func (s *Server) Close() {
+	s.Disconnect()
+	s.server.Close()
+}
+
+// Disconnect closes all open client connections but leaves the server running.
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = nil
+	s.mu.Unlock()
+
+	for _, ws := range conns {
+		ws.Close()
+	}
+}
+
+// Address returns the host the server is listening on.
+func (s *Server) Address() string {
+	host, _, _ := net.SplitHostPort(s.server.Listener.Addr().String())
+	return host
+}
+
+// Port returns the port the server is listening on.
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.server.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+// Status returns the current game status.
+func (s *Server) Status() api.Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// SetStatus changes the game status reported with each response.
+func (s *Server) SetStatus(status api.Status) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+}
+
+// GameLoop returns the current game loop.
+func (s *Server) GameLoop() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gameLoop
+}
+
+// EndGame ends the game immediately with the given results.
+func (s *Server) EndGame(results ...*api.PlayerResult) {
+	s.mu.Lock()
+	s.endGame(results)
+	s.mu.Unlock()
+}
+
+func (s *Server) endGame(results []*api.PlayerResult) {
+	s.status = api.Status_ended
+	s.results = results
+}
+
+// Requests returns a copy of every request received so far.
+func (s *Server) Requests() []*api.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*api.Request(nil), s.requests...)
+}
+
+// SavedMap returns the data saved to path with RequestSaveMap (or nil).
+func (s *Server) SavedMap(path string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maps[path]
+}
+
+func (s *Server) serve(ws *websocket.Conn) {
+	defer ws.Close()
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		req := &api.Request{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+
+		s.handleMu.Lock()
+		resp := s.handle(req)
+		s.handleMu.Unlock()
+		if resp.Id == 0 {
+			resp.Id = req.Id
+		}
+		if resp.Status == api.Status_nil {
+			resp.Status = s.Status()
+		}
+
+		if data, err = proto.Marshal(resp); err != nil {
+			return
+		}
+		if err := ws.WriteMessage(websocket.BinaryMessage, data); err != nil {
+			return
+		}
+
+		if _, ok := req.Request.(*api.Request_Quit); ok {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(req *api.Request) *api.Response {
+	if s.Handler != nil {
+		if resp := s.Handler(req); resp != nil {
+			return resp
+		}
+	}
+
+	// Like the real game, reject requests that aren't valid in the current state
+	switch status := s.Status(); req.Request.(type) {
+	case *api.Request_CreateGame:
+		if status != api.Status_launched {
+			return &api.Response{Error: []string{"sc2test: game already created"}}
+		}
+	case *api.Request_JoinGame:
+		if status != api.Status_launched && status != api.Status_init_game {
+			return &api.Response{Error: []string{"sc2test: game already joined"}}
+		}
+	case *api.Request_Step, *api.Request_Action, *api.Request_Query, *api.Request_QuickSave, *api.Request_QuickLoad, *api.Request_RestartGame:
+		if status != api.Status_in_game {
+			return &api.Response{Error: []string{"sc2test: not in game"}}
+		}
+	}
+
+	switch r := req.Request.(type) {
+	case *api.Request_Ping:
+		return &api.Response{Response: &api.Response_Ping{Ping: s.ping(r.Ping)}}
+	case *api.Request_CreateGame:
+		return &api.Response{Response: &api.Response_CreateGame{CreateGame: s.createGame(r.CreateGame)}}
+	case *api.Request_JoinGame:
+		return &api.Response{Response: &api.Response_JoinGame{JoinGame: s.joinGame(r.JoinGame)}}
+	case *api.Request_GameInfo:
+		return &api.Response{Response: &api.Response_GameInfo{GameInfo: s.gameInfo(r.GameInfo)}}
+	case *api.Request_Data:
+		return &api.Response{Response: &api.Response_Data{Data: s.data(r.Data)}}
+	case *api.Request_Observation:
+		return &api.Response{Response: &api.Response_Observation{Observation: s.observation(r.Observation)}}
+	case *api.Request_Step:
+		return &api.Response{Response: &api.Response_Step{Step: s.step(r.Step)}}
+	case *api.Request_Query:
+		return &api.Response{Response: &api.Response_Query{Query: s.query(r.Query)}}
+	case *api.Request_Action:
+		return &api.Response{Response: &api.Response_Action{Action: s.action(r.Action)}}
+	case *api.Request_Debug:
+		return &api.Response{Response: &api.Response_Debug{Debug: s.debug(r.Debug)}}
+	case *api.Request_LeaveGame:
+		return &api.Response{Response: &api.Response_LeaveGame{LeaveGame: s.leaveGame(r.LeaveGame)}}
+	case *api.Request_QuickSave:
+		s.mu.Lock()
+		s.saved = s.gameLoop
+		s.mu.Unlock()
+		return &api.Response{Response: &api.Response_QuickSave{QuickSave: &api.ResponseQuickSave{}}}
+	case *api.Request_QuickLoad:
+		s.mu.Lock()
+		s.gameLoop = s.saved
+		s.mu.Unlock()
+		return &api.Response{Response: &api.Response_QuickLoad{QuickLoad: &api.ResponseQuickLoad{}}}
+	case *api.Request_RestartGame:
+		s.mu.Lock()
+		s.gameLoop, s.saved = 0, 0
+		s.mu.Unlock()
+		return &api.Response{Response: &api.Response_RestartGame{RestartGame: &api.ResponseRestartGame{}}}
+	case *api.Request_SaveMap:
+		s.mu.Lock()
+		if s.maps == nil {
+			s.maps = map[string][]byte{}
+		}
+		s.maps[r.SaveMap.GetMapPath()] = r.SaveMap.GetMapData()
+		s.mu.Unlock()
+		return &api.Response{Response: &api.Response_SaveMap{SaveMap: &api.ResponseSaveMap{}}}
+	case *api.Request_Quit:
+		s.SetStatus(api.Status_quit)
+		return &api.Response{Response: &api.Response_Quit{Quit: &api.ResponseQuit{}}}
+	default:
+		return &api.Response{Error: []string{"sc2test: unsupported request"}}
+	}
+}
+
+func (s *Server) ping(r *api.RequestPing) *api.ResponsePing {
+	if s.Ping != nil {
+		return s.Ping(r)
+	}
+	return &api.ResponsePing{
+		GameVersion: "sc2test",
+		DataVersion: s.DataVersion,
+		DataBuild:   s.BaseBuild,
+		BaseBuild:   s.BaseBuild,
+	}
+}
+
+func (s *Server) createGame(r *api.RequestCreateGame) *api.ResponseCreateGame {
+	if s.CreateGame != nil {
+		return s.CreateGame(r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = api.Status_init_game
+	s.players = r.GetPlayerSetup()
+	return &api.ResponseCreateGame{}
+}
+
+func (s *Server) joinGame(r *api.RequestJoinGame) *api.ResponseJoinGame {
+	if s.JoinGame != nil {
+		return s.JoinGame(r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = api.Status_in_game
+	s.gameLoop = 0
+	s.results = nil
+	return &api.ResponseJoinGame{PlayerId: 1}
+}
+
+func (s *Server) gameInfo(r *api.RequestGameInfo) *api.ResponseGameInfo {
+	if s.GameInfo != nil {
+		return s.GameInfo(r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := &api.ResponseGameInfo{
+		MapName: "sc2test",
+		StartRaw: &api.StartRaw{
+			MapSize:       &api.Size2DI{X: 64, Y: 64},
+			PlayableArea:  &api.RectangleI{P0: &api.PointI{X: 0, Y: 0}, P1: &api.PointI{X: 64, Y: 64}},
+			PathingGrid:   &api.ImageData{BitsPerPixel: 1, Size_: &api.Size2DI{X: 64, Y: 64}, Data: make([]byte, 64*64/8)},
+			TerrainHeight: &api.ImageData{BitsPerPixel: 8, Size_: &api.Size2DI{X: 64, Y: 64}, Data: make([]byte, 64*64)},
+			PlacementGrid: &api.ImageData{BitsPerPixel: 1, Size_: &api.Size2DI{X: 64, Y: 64}, Data: make([]byte, 64*64/8)},
+		},
+	}
+	for i, p := range s.players {
+		info.PlayerInfo = append(info.PlayerInfo, &api.PlayerInfo{
+			PlayerId:      api.PlayerID(i + 1),
+			Type:          p.GetType(),
+			RaceRequested: p.GetRace(),
+			Difficulty:    p.GetDifficulty(),
+			PlayerName:    p.GetPlayerName(),
+		})
+	}
+	return info
+}
+
+func (s *Server) data(r *api.RequestData) *api.ResponseData {
+	if s.Data != nil {
+		return s.Data(r)
+	}
+	return &api.ResponseData{}
+}
+
+func (s *Server) observation(r *api.RequestObservation) *api.ResponseObservation {
+	if s.Observation != nil {
+		return s.Observation(r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// In realtime mode the game waits for the requested loop, just jump ahead
+	if r.GetGameLoop() > s.gameLoop && s.status == api.Status_in_game {
+		s.advance(r.GetGameLoop() - s.gameLoop)
+	}
+
+	return &api.ResponseObservation{
+		Observation: &api.Observation{
+			GameLoop:     s.gameLoop,
+			PlayerCommon: &api.PlayerCommon{PlayerId: 1},
+			RawData:      &api.ObservationRaw{Player: &api.PlayerRaw{}},
+		},
+		PlayerResult: s.results,
+	}
+}
+
+func (s *Server) step(r *api.RequestStep) *api.ResponseStep {
+	if s.Step != nil {
+		return s.Step(r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status == api.Status_in_game {
+		s.advance(r.GetCount())
+	}
+	return &api.ResponseStep{SimulationLoop: s.gameLoop}
+}
+
+// advance moves the game loop forward and ends the game when EndLoop is reached.
+func (s *Server) advance(count uint32) {
+	s.gameLoop += count
+	if s.EndLoop > 0 && s.gameLoop >= s.EndLoop {
+		s.gameLoop = s.EndLoop
+		s.endGame([]*api.PlayerResult{
+			{PlayerId: 1, Result: api.Result_Tie},
+			{PlayerId: 2, Result: api.Result_Tie},
+		})
+	}
+}
+
+func (s *Server) query(r *api.RequestQuery) *api.ResponseQuery {
+	if s.Query != nil {
+		return s.Query(r)
+	}
+
+	resp := &api.ResponseQuery{}
+	for range r.GetPathing() {
+		resp.Pathing = append(resp.Pathing, &api.ResponseQueryPathing{})
+	}
+	for _, a := range r.GetAbilities() {
+		resp.Abilities = append(resp.Abilities, &api.ResponseQueryAvailableAbilities{UnitTag: a.GetUnitTag()})
+	}
+	for range r.GetPlacements() {
+		resp.Placements = append(resp.Placements, &api.ResponseQueryBuildingPlacement{Result: api.ActionResult_Success})
+	}
+	return resp
+}
+
+func (s *Server) action(r *api.RequestAction) *api.ResponseAction {
+	if s.Action != nil {
+		return s.Action(r)
+	}
+
+	resp := &api.ResponseAction{}
+	for range r.GetActions() {
+		resp.Result = append(resp.Result, api.ActionResult_Success)
+	}
+	return resp
+}
+
+func (s *Server) debug(r *api.RequestDebug) *api.ResponseDebug {
+	if s.Debug != nil {
+		return s.Debug(r)
+	}
+
+	for _, cmd := range r.GetDebug() {
+		switch cmd.GetEndGame().GetEndResult() {
+		case api.DebugEndGame_Surrender:
+			s.EndGame(&api.PlayerResult{PlayerId: 1, Result: api.Result_Defeat},
+				&api.PlayerResult{PlayerId: 2, Result: api.Result_Victory})
+		case api.DebugEndGame_DeclareVictory:
+			s.EndGame(&api.PlayerResult{PlayerId: 1, Result: api.Result_Victory},
+				&api.PlayerResult{PlayerId: 2, Result: api.Result_Defeat})
+		}
+	}
+	return &api.ResponseDebug{}
+}
+
+func (s *Server) leaveGame(r *api.RequestLeaveGame) *api.ResponseLeaveGame {
+	if s.LeaveGame != nil {
+		return s.LeaveGame(r)
+	}
+
+	s.SetStatus(api.Status_launched)
+	return &api.ResponseLeaveGame{}
+}
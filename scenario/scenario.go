@@ -0,0 +1,340 @@
+// Package scenario sets up game situations with debug commands for tests and training.
+package scenario
+
+import (
+	"fmt"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+)
+
+// spawnRadius is how far from the requested position spawned units are looked for.
+const spawnRadius = 10
+
+// Scenario collects debug commands and applies them all at once with Run, e.g.
+//
+//	tags, err := scenario.New(bot).
+//		ClearUnits().
+//		Spawn(1, unit.Terran_Marine, 8, p).
+//		Spawn(2, unit.Zerg_Zergling, 6, q).
+//		Energy(50).
+//		FastBuild().
+//		Run()
+//
+// Run waits until the spawned units show up in an observation (and killed units are gone)
+// so callers don't need to count frames. The game must have been created with debug
+// commands allowed (which is the default outside of ladder games).
+type Scenario struct {
+	info    client.AgentInfo
+	timeout uint32
+
+	kills   []func(*api.Unit) bool
+	tags    []api.UnitTag
+	spawns  []*spawn
+	toggles []api.DebugGameState
+	end     api.DebugEndGame_EndResult
+}
+
+// spawn is a group of units created by a single Spawn call.
+type spawn struct {
+	create api.DebugCreateUnit
+	values []*api.DebugSetUnitValue
+	found  []api.UnitTag
+}
+
+// New creates an empty scenario.
+func New(info client.AgentInfo) *Scenario {
+	return &Scenario{info: info, timeout: 64}
+}
+
+// Timeout sets how many game loops Run waits for spawned units to appear (default 64).
+func (s *Scenario) Timeout(loops uint32) *Scenario {
+	s.timeout = loops
+	return s
+}
+
+// ClearMap kills every unit that isn't neutral. Note that in a melee game a player that
+// loses all of their structures is defeated, use ClearUnits instead.
+func (s *Scenario) ClearMap() *Scenario {
+	return s.KillAll(func(u *api.Unit) bool { return u.Alliance != api.Alliance_Neutral })
+}
+
+// ClearUnits kills every unit that isn't neutral or a structure, so players keep their
+// buildings and a melee game doesn't end.
+func (s *Scenario) ClearUnits() *Scenario {
+	return s.KillAll(func(u *api.Unit) bool {
+		return u.Alliance != api.Alliance_Neutral && !isStructure(s.info.Data(), u.UnitType)
+	})
+}
+
+// isStructure checks the game data for the structure attribute.
+func isStructure(data *api.ResponseData, unitType api.UnitTypeID) bool {
+	units := data.GetUnits()
+	if int(unitType) >= len(units) || units[unitType] == nil {
+		return false
+	}
+	for _, attr := range units[unitType].Attributes {
+		if attr == api.Attribute_Structure {
+			return true
+		}
+	}
+	return false
+}
+
+// ClearPlayer kills every visible unit owned by a player.
+func (s *Scenario) ClearPlayer(player api.PlayerID) *Scenario {
+	return s.KillAll(func(u *api.Unit) bool { return u.Owner == player })
+}
+
+// KillAll kills every visible unit matching filter when Run is called.
+func (s *Scenario) KillAll(filter func(*api.Unit) bool) *Scenario {
+	s.kills = append(s.kills, filter)
+	return s
+}
+
+// Kill kills specific units.
+func (s *Scenario) Kill(tags ...api.UnitTag) *Scenario {
+	s.tags = append(s.tags, tags...)
+	return s
+}
+
+// Spawn creates count units of the given type for a player at pos.
+func (s *Scenario) Spawn(owner api.PlayerID, unitType api.UnitTypeID, count int, pos api.Point2D) *Scenario {
+	s.spawns = append(s.spawns, &spawn{create: api.DebugCreateUnit{
+		UnitType: unitType,
+		Owner:    owner,
+		Pos:      &pos,
+		Quantity: uint32(count),
+	}})
+	return s
+}
+
+// Energy sets the energy of the units from the previous Spawn once they appear.
+func (s *Scenario) Energy(value float32) *Scenario {
+	return s.setValue(api.DebugSetUnitValue_Energy, value)
+}
+
+// Life sets the life of the units from the previous Spawn once they appear.
+func (s *Scenario) Life(value float32) *Scenario {
+	return s.setValue(api.DebugSetUnitValue_Life, value)
+}
+
+// Shields sets the shields of the units from the previous Spawn once they appear.
+func (s *Scenario) Shields(value float32) *Scenario {
+	return s.setValue(api.DebugSetUnitValue_Shields, value)
+}
+
+func (s *Scenario) setValue(kind api.DebugSetUnitValue_UnitValue, value float32) *Scenario {
+	if len(s.spawns) == 0 {
+		panic("scenario: unit values must follow a Spawn")
+	}
+	sp := s.spawns[len(s.spawns)-1]
+	sp.values = append(sp.values, &api.DebugSetUnitValue{UnitValue: kind, Value: value})
+	return s
+}
+
+// SetUnitValue sets the energy, life or shields of an existing unit.
+func (s *Scenario) SetUnitValue(tag api.UnitTag, kind api.DebugSetUnitValue_UnitValue, value float32) *Scenario {
+	s.spawns = append(s.spawns, &spawn{
+		values: []*api.DebugSetUnitValue{{UnitValue: kind, Value: value}},
+		found:  []api.UnitTag{tag},
+	})
+	return s
+}
+
+// Toggle flips a debug game state. The game only supports toggling, so applying the same
+// state twice turns it back off.
+func (s *Scenario) Toggle(state api.DebugGameState) *Scenario {
+	s.toggles = append(s.toggles, state)
+	return s
+}
+
+// ShowMap toggles revealing the whole map.
+func (s *Scenario) ShowMap() *Scenario {
+	return s.Toggle(api.DebugGameState_show_map)
+}
+
+// ControlEnemy toggles being able to control enemy units.
+func (s *Scenario) ControlEnemy() *Scenario {
+	return s.Toggle(api.DebugGameState_control_enemy)
+}
+
+// FastBuild toggles building, training and researching almost instantly.
+func (s *Scenario) FastBuild() *Scenario {
+	return s.Toggle(api.DebugGameState_fast_build)
+}
+
+// FreeBuild toggles everything costing no resources.
+func (s *Scenario) FreeBuild() *Scenario {
+	return s.Toggle(api.DebugGameState_free)
+}
+
+// NoCooldowns toggles abilities having no cooldowns.
+func (s *Scenario) NoCooldowns() *Scenario {
+	return s.Toggle(api.DebugGameState_cooldown)
+}
+
+// EndGame ends the game with the given result after everything else has been applied.
+func (s *Scenario) EndGame(result api.DebugEndGame_EndResult) *Scenario {
+	s.end = result
+	return s
+}
+
+// Run sends all of the commands and steps the game until the spawned units appear and the
+// killed units are gone. It returns the tags of the units created by each Spawn call, in
+// order (SetUnitValue calls count as a Spawn of the given unit). Units spawned for other
+// players where we can't see them are not waited for and have no tags. The scenario is
+// reset afterward so it can be reused.
+func (s *Scenario) Run() ([][]api.UnitTag, error) {
+	defer s.reset()
+
+	obs := s.info.Observation().GetObservation()
+	units := obs.GetRawData().GetUnits()
+	existing := make(map[api.UnitTag]bool, len(units))
+	kills := s.tags
+	for _, u := range units {
+		existing[u.Tag] = true
+		for _, filter := range s.kills {
+			if filter(u) {
+				kills = append(kills, u.Tag)
+				break
+			}
+		}
+	}
+
+	var cmds []*api.DebugCommand
+	if len(kills) > 0 {
+		cmds = append(cmds, &api.DebugCommand{Command: &api.DebugCommand_KillUnit{
+			KillUnit: &api.DebugKillUnit{Tag: kills},
+		}})
+	}
+	for _, sp := range s.spawns {
+		if sp.create.Quantity > 0 {
+			create := sp.create
+			cmds = append(cmds, &api.DebugCommand{Command: &api.DebugCommand_CreateUnit{CreateUnit: &create}})
+		}
+	}
+	for _, state := range s.toggles {
+		cmds = append(cmds, &api.DebugCommand{Command: &api.DebugCommand_GameState{GameState: state}})
+	}
+	if err := s.send(cmds); err != nil {
+		return nil, err
+	}
+
+	// Wait for the kills and spawns to show up
+	start := obs.GetGameLoop()
+	for !s.done(kills, existing) {
+		if loop := s.info.Observation().GetObservation().GetGameLoop(); loop-start >= s.timeout {
+			return s.found(), fmt.Errorf("scenario: units did not appear within %v game loops", s.timeout)
+		}
+		if err := s.info.Step(1); err != nil {
+			return s.found(), err
+		}
+	}
+
+	// Now that the tags are known the unit values can be set
+	cmds = nil
+	for _, sp := range s.spawns {
+		for _, tag := range sp.found {
+			for _, v := range sp.values {
+				value := *v
+				value.UnitTag = tag
+				cmds = append(cmds, &api.DebugCommand{Command: &api.DebugCommand_UnitValue{UnitValue: &value}})
+			}
+		}
+	}
+	if s.end != api.DebugEndGame_nil {
+		cmds = append(cmds, &api.DebugCommand{Command: &api.DebugCommand_EndGame{
+			EndGame: &api.DebugEndGame{EndResult: s.end},
+		}})
+	}
+	if len(cmds) > 0 {
+		if err := s.send(cmds); err != nil {
+			return s.found(), err
+		}
+		if err := s.info.Step(1); err != nil {
+			return s.found(), err
+		}
+	}
+	return s.found(), nil
+}
+
+// done matches new units in the current observation to the spawn groups and returns true
+// once every group is complete and none of the killed units remain.
+func (s *Scenario) done(kills []api.UnitTag, existing map[api.UnitTag]bool) bool {
+	obs := s.info.Observation().GetObservation()
+	units := obs.GetRawData().GetUnits()
+
+	present := make(map[api.UnitTag]bool, len(units))
+	for _, u := range units {
+		present[u.Tag] = true
+	}
+	for _, tag := range kills {
+		if present[tag] {
+			return false
+		}
+	}
+
+	claimed := map[api.UnitTag]bool{}
+	for _, sp := range s.spawns {
+		for _, tag := range sp.found {
+			claimed[tag] = true
+		}
+	}
+
+	var visibility api.ImageDataBytes
+	if v := obs.GetRawData().GetMapState().GetVisibility(); v != nil {
+		visibility = v.Bytes()
+	}
+
+	complete := true
+	for _, sp := range s.spawns {
+		want := int(sp.create.Quantity)
+		for _, u := range units {
+			if len(sp.found) >= want {
+				break
+			}
+			if existing[u.Tag] || claimed[u.Tag] || u.DisplayType != api.DisplayType_Visible ||
+				u.Owner != sp.create.Owner || u.UnitType != sp.create.UnitType ||
+				u.Pos.ToPoint2D().Distance(*sp.create.Pos) > spawnRadius {
+				continue
+			}
+			sp.found = append(sp.found, u.Tag)
+			claimed[u.Tag] = true
+		}
+		if len(sp.found) < want && !hidden(sp, s.info.PlayerID(), visibility) {
+			complete = false
+		}
+	}
+	return complete
+}
+
+// hidden returns true if the group belongs to another player and spawned where we can't see.
+func hidden(sp *spawn, self api.PlayerID, visibility api.ImageDataBytes) bool {
+	if sp.create.Owner == self {
+		return false
+	}
+	x, y := int32(sp.create.Pos.X), int32(sp.create.Pos.Y)
+	return visibility.InBounds(x, y) && visibility.Get(x, y) != 2 // 2 = visible
+}
+
+// found returns the tags found for each group.
+func (s *Scenario) found() [][]api.UnitTag {
+	tags := make([][]api.UnitTag, len(s.spawns))
+	for i, sp := range s.spawns {
+		tags[i] = sp.found
+	}
+	return tags
+}
+
+func (s *Scenario) send(cmds []*api.DebugCommand) error {
+	if len(cmds) == 0 {
+		return nil
+	}
+	return s.info.SendDebugCommands(cmds)
+}
+
+func (s *Scenario) reset() {
+	s.kills, s.tags, s.spawns, s.toggles = nil, nil, nil, nil
+	s.end = api.DebugEndGame_nil
+}
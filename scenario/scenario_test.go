@@ -0,0 +1,127 @@
+package scenario_test
+
+import (
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/enums/unit"
+	"github.com/chippydip/go-sc2ai/sc2test"
+	"github.com/chippydip/go-sc2ai/scenario"
+)
+
+// fakeGame creates debug units one step after they are requested.
+type fakeGame struct {
+	units   []*api.Unit
+	pending []*api.Unit
+	values  []*api.DebugSetUnitValue
+	states  []api.DebugGameState
+	nextTag api.UnitTag
+	frozen  bool // never create the requested units
+}
+
+// newFakeServer starts a server that uses g for debug commands and observations.
+func newFakeServer(g *fakeGame) *sc2test.Server {
+	s := &sc2test.Server{Debug: g.debug}
+	s.Observation = func(*api.RequestObservation) *api.ResponseObservation {
+		return &api.ResponseObservation{Observation: &api.Observation{
+			GameLoop:     s.GameLoop(),
+			PlayerCommon: &api.PlayerCommon{PlayerId: 1},
+			RawData:      &api.ObservationRaw{Player: &api.PlayerRaw{}, Units: g.units},
+		}}
+	}
+	s.Handler = func(r *api.Request) *api.Response {
+		if r.GetStep() != nil {
+			g.step()
+		}
+		return nil // use the default behavior
+	}
+	s.Start()
+	return s
+}
+
+func (g *fakeGame) debug(r *api.RequestDebug) *api.ResponseDebug {
+	for _, cmd := range r.Debug {
+		switch c := cmd.Command.(type) {
+		case *api.DebugCommand_CreateUnit:
+			for i := uint32(0); i < c.CreateUnit.Quantity; i++ {
+				g.nextTag++
+				pos := c.CreateUnit.Pos.ToPoint()
+				g.pending = append(g.pending, &api.Unit{
+					Tag:         g.nextTag,
+					UnitType:    c.CreateUnit.UnitType,
+					Owner:       c.CreateUnit.Owner,
+					Pos:         &pos,
+					DisplayType: api.DisplayType_Visible,
+				})
+			}
+		case *api.DebugCommand_KillUnit:
+			for _, tag := range c.KillUnit.Tag {
+				for i, u := range g.units {
+					if u.Tag == tag {
+						g.units = append(g.units[:i], g.units[i+1:]...)
+						break
+					}
+				}
+			}
+		case *api.DebugCommand_UnitValue:
+			g.values = append(g.values, c.UnitValue)
+		case *api.DebugCommand_GameState:
+			g.states = append(g.states, c.GameState)
+		}
+	}
+	return &api.ResponseDebug{}
+}
+
+func (g *fakeGame) step() {
+	if g.frozen {
+		g.pending = nil
+	}
+	g.units = append(g.units, g.pending...)
+	g.pending = nil
+}
+
+func TestScenario(t *testing.T) {
+	g := &fakeGame{nextTag: 100, units: []*api.Unit{
+		{Tag: 1, UnitType: unit.Terran_CommandCenter, Owner: 1, Alliance: api.Alliance_Self, Pos: &api.Point{}},
+		{Tag: 2, UnitType: unit.Neutral_MineralField, Owner: 16, Alliance: api.Alliance_Neutral, Pos: &api.Point{}},
+	}}
+	s := newFakeServer(g)
+	defer s.Close()
+
+	c := sc2test.NewGame(t, s)
+
+	tags, err := scenario.New(c).
+		ClearMap().
+		Spawn(1, unit.Terran_Marine, 8, api.Point2D{X: 20, Y: 20}).
+		Spawn(2, unit.Zerg_Zergling, 6, api.Point2D{X: 30, Y: 20}).
+		Energy(50).
+		FastBuild().
+		Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 2 || len(tags[0]) != 8 || len(tags[1]) != 6 {
+		t.Fatalf("tags = %v", tags)
+	}
+	if len(g.units) != 15 || g.units[0].Tag != 2 {
+		t.Errorf("command center not killed or mineral killed: %v", g.units)
+	}
+	if len(g.values) != 6 || g.values[0].UnitTag != tags[1][0] || g.values[0].Value != 50 {
+		t.Errorf("values = %v", g.values)
+	}
+	if len(g.states) != 1 || g.states[0] != api.DebugGameState_fast_build {
+		t.Errorf("states = %v", g.states)
+	}
+
+	// Units that never appear time out
+	g = &fakeGame{frozen: true}
+	s = newFakeServer(g)
+	defer s.Close()
+
+	c = sc2test.NewGame(t, s)
+	_, err = scenario.New(c).Timeout(8).Spawn(1, unit.Terran_Marine, 1, api.Point2D{}).Run()
+	if err == nil {
+		t.Error("expected timeout error")
+	}
+}
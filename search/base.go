@@ -1,7 +1,7 @@
 package search
 
 import (
-	"log"
+	"fmt"
 	"strings"
 
 	"github.com/chippydip/go-sc2ai/api"
@@ -61,7 +61,7 @@ func (base *Base) updateResource(u botutil.Unit) {
 	case u.HasVespene:
 		base.Geysers = base.updateOrAdd(base.Geysers, u)
 	default:
-		log.Panicf("unknown resource: %v", u)
+		panic(fmt.Sprintf("unknown resource: %v", u))
 	}
 }
 
@@ -93,7 +93,7 @@ func (base *Base) updateOrAdd(units []botutil.Unit, u botutil.Unit) []botutil.Un
 	for i, u2 := range units {
 		if u2.Pos2D().Distance2(u.Pos2D()) < 1 {
 			if u2.Pos2D() != u.Pos2D() {
-				log.Panicf("%v != %v", u2.Pos2D(), u.Pos2D())
+				panic(fmt.Sprintf("%v != %v", u2.Pos2D(), u.Pos2D()))
 			}
 
 			units[i] = u
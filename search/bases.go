@@ -53,8 +53,11 @@ func newBases(m *Map, bot *botutil.Bot) bases {
 		}
 	}
 
-	resp := bot.Query(api.RequestQuery{Pathing: query})
-	for k, r := range resp.Pathing {
+	resp, err := bot.Query(api.RequestQuery{Pathing: query})
+	if err != nil {
+		bot.Logger().Error("Failed to query base distances", "err", err)
+	}
+	for k, r := range resp.GetPathing() {
 		// Take the maximum computed distance
 		if b.distances[k/2] < r.Distance {
 			b.distances[k/2] = r.Distance
@@ -1,7 +1,7 @@
 package search
 
 import (
-	"log"
+	"log/slog"
 
 	"github.com/chippydip/go-sc2ai/api"
 	"github.com/chippydip/go-sc2ai/botutil"
@@ -56,7 +56,7 @@ func UnitPlacementSize(u botutil.Unit) api.Size2DI {
 	// Cache and return the computed size
 	size := api.Size2DI{X: xMax - xMin, Y: yMax - yMin}
 	sizeCache[u.UnitType] = size
-	log.Printf("%v %v %v -> %v", unit.String(u.UnitType), u.Pos2D(), u.Radius, size)
+	slog.Debug("Computed placement size", "unit", unit.String(u.UnitType), "pos", u.Pos2D(), "radius", u.Radius, "size", size)
 	return size
 }
 
@@ -122,9 +122,12 @@ func NewPlacementGrid(bot *botutil.Bot) *PlacementGrid {
 		}
 	}
 
-	resp := bot.Query(api.RequestQuery{
+	resp, err := bot.Query(api.RequestQuery{
 		Placements: req,
 	})
+	if err != nil {
+		bot.Logger().Error("Failed to query placements", "err", err)
+	}
 
 	heightMap := NewHeightMap(bot.GameInfo().StartRaw)
 	var ok, inval = 0, 0
@@ -145,7 +148,7 @@ func NewPlacementGrid(bot *botutil.Bot) *PlacementGrid {
 			Max:   &api.Point{X: v.X + 0.375, Y: v.Y + 0.375, Z: z + 1},
 		})
 	}
-	log.Printf("ok: %v, inval: %v", ok, inval)
+	bot.Logger().Debug("Placement grid check", "ok", ok, "inval", inval)
 
 	return pg
 }
@@ -0,0 +1,199 @@
+package tournament
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Elo rating parameters
+const (
+	EloStart = 1500
+	EloK     = 32
+)
+
+// Stats summarizes the games of one player, either overall or limited to a single map or
+// opponent race. Games that couldn't be played are not counted.
+type Stats struct {
+	Player  string  `json:"player"`
+	Map     string  `json:"map,omitempty"`  // only games on this map
+	Race    string  `json:"race,omitempty"` // only games against this race
+	Games   int     `json:"games"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Ties    int     `json:"ties"`
+	Crashes int     `json:"crashes"`
+	WinRate float64 `json:"win_rate"` // ties count as half a win
+	Elo     float64 `json:"elo"`      // rated only on the same subset of games
+}
+
+// Summarize computes stats for each player overall, per map and per opponent race, sorted
+// by player and then overall stats first.
+func Summarize(results []Result) []Stats {
+	type key struct{ player, m, race string }
+	stats := map[key]*Stats{}
+	var keys []key
+
+	add := func(k key, r Result, side int) {
+		s := stats[k]
+		if s == nil {
+			s = &Stats{Player: k.player, Map: k.m, Race: k.race}
+			stats[k] = s
+			keys = append(keys, k)
+		}
+		s.Games++
+		switch {
+		case len(r.Winner) == 0:
+			s.Ties++
+		case r.Winner == k.player:
+			s.Wins++
+		default:
+			s.Losses++
+		}
+		if r.Crashed[side] {
+			s.Crashes++
+		}
+	}
+
+	for _, r := range results {
+		if len(r.Error) > 0 {
+			continue
+		}
+		for side, player := range r.Players {
+			opponentRace := r.Races[1-side]
+			add(key{player, "", ""}, r, side)
+			if m := mapName(r.Map); len(m) > 0 {
+				add(key{player, m, ""}, r, side)
+			}
+			add(key{player, "", opponentRace}, r, side)
+		}
+	}
+
+	out := make([]Stats, 0, len(keys))
+	for _, k := range keys {
+		s := stats[k]
+		s.WinRate = (float64(s.Wins) + float64(s.Ties)/2) / float64(s.Games)
+		s.Elo = elo(results, func(r Result, side int) bool {
+			return (len(k.m) == 0 || mapName(r.Map) == k.m) && (len(k.race) == 0 || r.Races[1-side] == k.race)
+		})[k.player]
+		out = append(out, *s)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Player != b.Player {
+			return a.Player < b.Player
+		}
+		if a.Map != b.Map {
+			return a.Map < b.Map
+		}
+		return a.Race < b.Race
+	})
+	return out
+}
+
+// elo rates all players over the games in order. Only games where include returns true for
+// either side are rated, and only that side's rating changes.
+func elo(results []Result, include func(r Result, side int) bool) map[string]float64 {
+	ratings := map[string]float64{}
+	rating := func(player string) float64 {
+		if r, ok := ratings[player]; ok {
+			return r
+		}
+		return EloStart
+	}
+
+	for _, r := range results {
+		if len(r.Error) > 0 {
+			continue
+		}
+		a, b := rating(r.Players[0]), rating(r.Players[1])
+		expected := 1 / (1 + math.Pow(10, (b-a)/400))
+
+		score := 0.5
+		switch r.Winner {
+		case "":
+		case r.Players[0]:
+			score = 1
+		default:
+			score = 0
+		}
+
+		if include(r, 0) {
+			ratings[r.Players[0]] = a + EloK*(score-expected)
+		}
+		if include(r, 1) {
+			ratings[r.Players[1]] = b + EloK*(expected-score)
+		}
+	}
+	return ratings
+}
+
+// Save writes results (or stats) to a file. The format is CSV if the file extension is .csv
+// and JSON otherwise.
+func Save(path string, v interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		err = WriteCSV(f, v)
+	} else {
+		err = WriteJSON(f, v)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteJSON writes results (or stats) as indented JSON.
+func WriteJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// WriteCSV writes a []Result or []Stats as CSV with one row per element.
+func WriteCSV(w io.Writer, v interface{}) error {
+	var rows [][]string
+	switch v := v.(type) {
+	case []Result:
+		rows = append(rows, []string{"game", "map", "player1", "player2", "race1", "race2", "result1", "result2",
+			"winner", "game_loop", "duration", "replay", "crashed1", "crashed2", "error"})
+		for _, r := range v {
+			rows = append(rows, []string{
+				strconv.Itoa(r.Game), r.Map, r.Players[0], r.Players[1], r.Races[0], r.Races[1],
+				r.Results[0], r.Results[1], r.Winner, strconv.FormatUint(uint64(r.GameLoop), 10),
+				strconv.FormatFloat(r.Duration, 'f', 1, 64), r.Replay,
+				strconv.FormatBool(r.Crashed[0]), strconv.FormatBool(r.Crashed[1]), r.Error,
+			})
+		}
+	case []Stats:
+		rows = append(rows, []string{"player", "map", "race", "games", "wins", "losses", "ties", "crashes", "win_rate", "elo"})
+		for _, s := range v {
+			rows = append(rows, []string{
+				s.Player, s.Map, s.Race, strconv.Itoa(s.Games), strconv.Itoa(s.Wins), strconv.Itoa(s.Losses),
+				strconv.Itoa(s.Ties), strconv.Itoa(s.Crashes),
+				strconv.FormatFloat(s.WinRate, 'f', 3, 64), strconv.FormatFloat(s.Elo, 'f', 0, 64),
+			})
+		}
+	default:
+		return fmt.Errorf("tournament: can't write %T as CSV", v)
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.WriteAll(rows); err != nil {
+		return err
+	}
+	return out.Error()
+}
@@ -0,0 +1,228 @@
+// Package tournament plays many games between bots (in-process agents, bot programs and
+// computer opponents) and summarizes the results with win rates and Elo ratings.
+package tournament
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+	"github.com/chippydip/go-sc2ai/runner"
+)
+
+// PortsPerGame is the size of the port range used by each concurrent game.
+const PortsPerGame = 20
+
+// Format determines who plays whom.
+type Format int
+
+// Tournament formats
+const (
+	RoundRobin Format = iota // every player plays every other player
+	Series                   // the first player plays every other player
+)
+
+// Tournament is a set of players and the games they should play. Players are created with
+// client.NewParticipant, client.NewComputer or runner.NewBotProcess and must have unique
+// names (computers without a name are named after their difficulty and race). Games between
+// two computers are skipped.
+type Tournament struct {
+	Players []client.PlayerSetup
+	Maps    []string
+	Format  Format
+
+	// Games is the number of games each pairing plays on each map (default 1). The players
+	// switch sides every game.
+	Games int
+	// Concurrency is the number of games played at the same time (default 1).
+	Concurrency int
+	// PortStart is the first port used, each concurrent game uses PortsPerGame ports.
+	PortStart int
+	// ReplayDir is a directory to save replays to (if set).
+	ReplayDir string
+	// Realtime plays the games in realtime mode.
+	Realtime bool
+	// MaxGameLoop stops each game at this game loop with no winner (if non-zero), see
+	// runner.MatchOptions.
+	MaxGameLoop uint32
+	// Timeout stops each game that takes longer than this and records an error (if non-zero).
+	Timeout time.Duration
+
+	// OnResult is called after each game, e.g. to report progress.
+	OnResult func(Result)
+}
+
+// Game is a scheduled game, Players are indexes into Tournament.Players.
+type Game struct {
+	Map     string
+	Players [2]int
+}
+
+// Result is the outcome of a single game.
+type Result struct {
+	Game     int       `json:"game"`
+	Map      string    `json:"map"`
+	Players  [2]string `json:"players"`
+	Races    [2]string `json:"races"`
+	Results  [2]string `json:"results"`
+	Winner   string    `json:"winner"` // empty if there was no winner
+	GameLoop uint32    `json:"game_loop"`
+	Duration float64   `json:"duration"` // in seconds
+	Replay   string    `json:"replay"`
+	Crashed  [2]bool   `json:"crashed"`
+	Error    string    `json:"error"` // set if the game couldn't be played
+}
+
+// Schedule returns the games to play in order.
+func (t *Tournament) Schedule() []Game {
+	games := t.Games
+	if games < 1 {
+		games = 1
+	}
+
+	var pairs [][2]int
+	for i := range t.Players {
+		for j := i + 1; j < len(t.Players); j++ {
+			if t.Format == Series && i > 0 {
+				break
+			}
+			if isComputer(t.Players[i]) && isComputer(t.Players[j]) {
+				continue
+			}
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+
+	var schedule []Game
+	for n := 0; n < games; n++ {
+		for _, m := range t.Maps {
+			for _, p := range pairs {
+				if n%2 == 1 {
+					p[0], p[1] = p[1], p[0]
+				}
+				schedule = append(schedule, Game{m, p})
+			}
+		}
+	}
+	return schedule
+}
+
+// Run plays all scheduled games and returns the results in schedule order.
+func (t *Tournament) Run() []Result {
+	for i, p := range t.Players {
+		if isComputer(p) && len(p.PlayerName) == 0 {
+			t.Players[i].PlayerName = fmt.Sprintf("%v%v", p.Difficulty, p.Race)
+		}
+	}
+
+	// Matches raise the message size for their map before connecting, do it for all of them
+	// up front instead of while other games are running
+	if err := runner.SizeMessagesForMaps(t.Maps...); err != nil {
+		slog.Warn("Unable to check the map sizes", "err", err)
+	}
+
+	schedule := t.Schedule()
+	results := make([]Result, len(schedule))
+
+	concurrency := t.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var mu sync.Mutex
+	next := 0
+	take := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if next >= len(schedule) {
+			return 0, false
+		}
+		next++
+		return next - 1, true
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			i, ok := take()
+			for ok {
+				j, more := take()
+				results[i] = t.play(i, schedule[i], t.PortStart+w*PortsPerGame, !more)
+
+				if t.OnResult != nil {
+					mu.Lock()
+					t.OnResult(results[i])
+					mu.Unlock()
+				}
+				i, ok = j, more
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// play runs a single game. The game instances are stopped after the last game on each port.
+func (t *Tournament) play(n int, g Game, portStart int, last bool) Result {
+	p1, p2 := t.Players[g.Players[0]], t.Players[g.Players[1]]
+	r := Result{
+		Game:    n + 1,
+		Map:     g.Map,
+		Players: [2]string{p1.PlayerName, p2.PlayerName},
+		Races:   [2]string{p1.Race.String(), p2.Race.String()},
+	}
+
+	opts := runner.MatchOptions{
+		Map:         g.Map,
+		Realtime:    t.Realtime,
+		PortStart:   portStart,
+		Kill:        last,
+		MaxGameLoop: t.MaxGameLoop,
+		Timeout:     t.Timeout,
+	}
+	if len(t.ReplayDir) > 0 {
+		name := fmt.Sprintf("%04d_%v_%v_vs_%v.SC2Replay", n+1, mapName(g.Map), p1.PlayerName, p2.PlayerName)
+		opts.Replay = filepath.Join(t.ReplayDir, name)
+	}
+
+	m, err := runner.RunMatch(p1, p2, opts)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	r.Results = [2]string{m.Results[0].String(), m.Results[1].String()}
+	switch {
+	case m.Results[0] == api.Result_Victory:
+		r.Winner = p1.PlayerName
+	case m.Results[1] == api.Result_Victory:
+		r.Winner = p2.PlayerName
+	}
+	r.GameLoop = m.GameLoop
+	r.Duration = m.Duration.Seconds()
+	r.Replay = m.Replay
+	r.Crashed = m.Crashed
+	return r
+}
+
+func isComputer(p client.PlayerSetup) bool {
+	return p.Type == api.PlayerType_Computer
+}
+
+// mapName returns the name of a map without the directory or extension.
+func mapName(path string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
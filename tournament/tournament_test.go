@@ -0,0 +1,82 @@
+package tournament_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/chippydip/go-sc2ai/api"
+	"github.com/chippydip/go-sc2ai/client"
+	"github.com/chippydip/go-sc2ai/tournament"
+)
+
+func TestSchedule(t *testing.T) {
+	bot := func(name string) client.PlayerSetup {
+		return client.NewParticipant(api.Race_Terran, client.AgentFunc(func(client.AgentInfo) {}), name)
+	}
+	tr := tournament.Tournament{
+		Players: []client.PlayerSetup{
+			bot("A"), bot("B"),
+			client.NewComputer(api.Race_Zerg, api.Difficulty_Easy, api.AIBuild_RandomBuild),
+			client.NewComputer(api.Race_Zerg, api.Difficulty_Hard, api.AIBuild_RandomBuild),
+		},
+		Maps:  []string{"M1", "M2"},
+		Games: 2,
+	}
+
+	// A-B, A-C1, A-C2, B-C1, B-C2 (no C1-C2) on two maps, twice
+	games := tr.Schedule()
+	if len(games) != 5*2*2 {
+		t.Fatalf("got %v games, want 20", len(games))
+	}
+	if g := games[0]; g.Map != "M1" || g.Players != [2]int{0, 1} {
+		t.Errorf("first game = %+v", g)
+	}
+	if g := games[10]; g.Map != "M1" || g.Players != [2]int{1, 0} {
+		t.Errorf("first game of the second round = %+v, want sides switched", g)
+	}
+
+	tr.Format = tournament.Series
+	if n := len(tr.Schedule()); n != 3*2*2 {
+		t.Errorf("series has %v games, want 12", n)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	results := []tournament.Result{
+		{Map: "maps/M1.SC2Map", Players: [2]string{"A", "B"}, Races: [2]string{"Terran", "Zerg"}, Winner: "A"},
+		{Map: "maps/M2.SC2Map", Players: [2]string{"B", "A"}, Races: [2]string{"Zerg", "Terran"}, Winner: "A", Crashed: [2]bool{true, false}},
+		{Map: "maps/M1.SC2Map", Players: [2]string{"A", "B"}, Races: [2]string{"Terran", "Zerg"}},
+		{Map: "maps/M1.SC2Map", Players: [2]string{"A", "B"}, Error: "failed"},
+	}
+
+	stats := map[string]tournament.Stats{}
+	for _, s := range tournament.Summarize(results) {
+		stats[s.Player+"/"+s.Map+"/"+s.Race] = s
+	}
+
+	a := stats["A//"]
+	if a.Games != 3 || a.Wins != 2 || a.Ties != 1 || a.Losses != 0 || a.WinRate != 2.5/3 {
+		t.Errorf("A = %+v", a)
+	}
+	if b := stats["B//"]; b.Losses != 2 || b.Crashes != 1 || b.Elo >= tournament.EloStart || a.Elo+b.Elo != 2*tournament.EloStart {
+		t.Errorf("B = %+v (A elo %v)", b, a.Elo)
+	}
+	if m := stats["A/M1/"]; m.Games != 2 || m.Wins != 1 {
+		t.Errorf("A on M1 = %+v", m)
+	}
+	if r := stats["B//Terran"]; r.Games != 3 {
+		t.Errorf("B vs Terran = %+v", r)
+	}
+
+	var buf bytes.Buffer
+	if err := tournament.WriteCSV(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(results)+1 {
+		t.Errorf("CSV has %v lines, want %v", lines, len(results)+1)
+	}
+	if err := tournament.WriteCSV(&buf, 42); err == nil {
+		t.Error("expected an error writing an int as CSV")
+	}
+}